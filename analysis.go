@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+)
+
+// ErrNoPath is wrapped by the errors of colonies where no path leads the ants from ##start to ##end
+var ErrNoPath = errors.New("no path from ##start to ##end")
+
+// noPathError keeps the message of an error while marking it as an ErrNoPath
+type noPathError struct {
+	message string
+}
+
+func (e noPathError) Error() string { return e.message }
+
+func (e noPathError) Unwrap() error { return ErrNoPath }
+
+// ErrInvalid is wrapped by the errors of colonies that don't follow the format
+var ErrInvalid = errors.New("invalid data format")
+
+// invalidError keeps the message of an error while marking it as an ErrInvalid
+type invalidError struct {
+	message string
+}
+
+func (e invalidError) Error() string { return e.message }
+
+func (e invalidError) Unwrap() error { return ErrInvalid }
+
+// undirected lists the neighbours of every room with each link in both directions, whichever way AddLinks stored it
+func undirected(g *Graph) map[string][]string {
+	adjacency := map[string][]string{}
+	for _, room := range g.Rooms {
+		if _, ok := adjacency[room.Roomname]; !ok {
+			adjacency[room.Roomname] = nil
+		}
+		for _, name := range room.Connections {
+			if name == "" {
+				continue
+			}
+			if !contains(adjacency[room.Roomname], name) {
+				adjacency[room.Roomname] = append(adjacency[room.Roomname], name)
+			}
+			if !contains(adjacency[name], room.Roomname) {
+				adjacency[name] = append(adjacency[name], room.Roomname)
+			}
+		}
+	}
+	return adjacency
+}
+
+// countLinks counts every link once, whichever direction it was stored in
+func countLinks(adjacency map[string][]string) int {
+	links := 0
+	for _, neighbours := range adjacency {
+		links += len(neighbours)
+	}
+	return links / 2
+}
+
+// UsefulRooms finds every room that lies on at least one path from the start room to the end room.
+// With an extra link between start and end, those are exactly the rooms in the biconnected component of that link
+func UsefulRooms(neighbours map[string][]string, start, end string) map[string]bool {
+	adjacency := map[string][]string{}
+	for room, names := range neighbours {
+		adjacency[room] = append([]string{}, names...)
+	}
+	if !contains(adjacency[start], end) {
+		adjacency[start] = append(adjacency[start], end)
+		adjacency[end] = append(adjacency[end], start)
+	}
+
+	discovered := map[string]int{}
+	low := map[string]int{}
+	var stack [][2]string
+	var useful map[string]bool
+	time := 0
+
+	var visit func(room, parent string)
+	visit = func(room, parent string) {
+		time++
+		discovered[room], low[room] = time, time
+		for _, next := range adjacency[room] {
+			switch {
+			case discovered[next] == 0:
+				stack = append(stack, [2]string{room, next})
+				visit(next, room)
+				if low[next] < low[room] {
+					low[room] = low[next]
+				}
+				if low[next] >= discovered[room] {
+					// everything on the stack down to this link is one biconnected component
+					component := map[string]bool{}
+					holdsStartToEnd := false
+					for {
+						link := stack[len(stack)-1]
+						stack = stack[:len(stack)-1]
+						component[link[0]], component[link[1]] = true, true
+						if tunnelKey(link[0], link[1]) == tunnelKey(start, end) {
+							holdsStartToEnd = true
+						}
+						if link == [2]string{room, next} {
+							break
+						}
+					}
+					if holdsStartToEnd {
+						useful = component
+					}
+				}
+			case next != parent && discovered[next] < discovered[room]:
+				stack = append(stack, [2]string{room, next})
+				if discovered[next] < low[room] {
+					low[room] = discovered[next]
+				}
+			}
+		}
+	}
+	visit(start, "")
+	return useful
+}
+
+// PruneGraph checks that the end room can be reached from the start room and removes every room that can't lie on
+// a path between them, such as dead-end branches and rooms cut off from the rest of the colony. With checkpoints the
+// route can have to walk into a dead end and back, so only the rooms cut off from the start room are removed.
+// It returns how many rooms and links were removed
+func PruneGraph(g *Graph) (int, int, error) {
+	if g.getRoom(g.StartRoomName) == nil || g.getRoom(g.EndRoomName) == nil {
+		return 0, 0, invalidError{"ERROR: invalid data format. No ##start or ##end room"}
+	}
+	adjacency := undirected(g)
+	if !Reachable(adjacency, g.StartRoomName)[g.EndRoomName] {
+		return 0, 0, noPathError{"ERROR: invalid data format. The ##end room can't be reached from the ##start room"}
+	}
+	linksBefore := countLinks(adjacency)
+
+	useful := UsefulRooms(adjacency, g.StartRoomName, g.EndRoomName)
+	if len(g.Checkpoints) > 0 {
+		useful = Reachable(adjacency, g.StartRoomName)
+	}
+	rooms := []*Room{}
+	for _, room := range g.Rooms {
+		if !useful[room.Roomname] {
+			continue
+		}
+		connections := []string{}
+		for _, name := range room.Connections {
+			if name == "" || useful[name] {
+				connections = append(connections, name)
+			}
+		}
+		room.Connections = connections
+		rooms = append(rooms, room)
+	}
+	prunedRooms := len(g.Rooms) - len(rooms)
+	g.Rooms, g.index = rooms, nil
+	return prunedRooms, linksBefore - countLinks(undirected(g)), nil
+}
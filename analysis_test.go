@@ -0,0 +1,56 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPruneGraph(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		colony string
+		rooms  string // the rooms left, sorted
+		pruned [2]int // rooms and links removed
+		err    string
+	}{
+		{"nothing to prune", "1\n##start\ns 0 0\na 1 1\n##end\ne 2 2\ns-a\na-e", "a e s", [2]int{0, 0}, ""},
+		{"dead end", "1\n##start\ns 0 0\na 1 1\nd 3 3\n##end\ne 2 2\ns-a\na-e\na-d", "a e s", [2]int{1, 1}, ""},
+		{"dead-end branch", "1\n##start\ns 0 0\na 1 1\nd 3 3\nf 4 4\n##end\ne 2 2\ns-a\na-e\na-d\nd-f", "a e s", [2]int{2, 2}, ""},
+		{"loop off the corridor", "1\n##start\ns 0 0\na 1 1\nd 3 3\nf 4 4\n##end\ne 2 2\ns-a\na-e\na-d\nd-f\nf-a", "a e s", [2]int{2, 3}, ""},
+		{"behind the end room", "1\n##start\ns 0 0\na 1 1\nd 3 3\n##end\ne 2 2\ns-a\na-e\ne-d", "a e s", [2]int{1, 1}, ""},
+		{"cut off", "1\n##start\ns 0 0\na 1 1\nc 3 3\nd 4 4\n##end\ne 2 2\ns-a\na-e\nc-d", "a e s", [2]int{2, 1}, ""},
+		{"cycle through start and end", "1\n##start\ns 0 0\na 1 1\nb 3 3\n##end\ne 2 2\ns-a\na-e\ns-b\nb-e\na-b", "a b e s", [2]int{0, 0}, ""},
+		{"dead end with a checkpoint", "1\n##start\ns 0 0\na 1 1\n##checkpoint\nd 3 3\nc 4 4\nf 5 5\n##end\ne 2 2\ns-a\na-e\na-d\nc-f", "a d e s", [2]int{2, 1}, ""},
+		{"unreachable end", "1\n##start\ns 0 0\na 1 1\nb 3 3\n##end\ne 2 2\ns-a\ne-b", "", [2]int{}, "can't be reached"},
+	} {
+		g, _, err := ParseGraph(strings.NewReader(test.colony))
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		rooms, links, err := PruneGraph(g)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) || ExitCode(err) != ExitNoPath {
+				t.Errorf("%v: got %v, want a no path error about %q", test.name, err, test.err)
+			}
+			continue
+		}
+		names := []string{}
+		for _, room := range g.Rooms {
+			names = append(names, room.Roomname)
+		}
+		sort.Strings(names)
+		if err != nil || strings.Join(names, " ") != test.rooms || [2]int{rooms, links} != test.pruned {
+			t.Errorf("%v: rooms %v, pruned %d rooms and %d links, %v; want rooms %v, pruned %v", test.name, names, rooms, links, err, test.rooms, test.pruned)
+		}
+		// no room left links to a pruned one
+		for _, room := range g.Rooms {
+			for _, name := range room.Connections {
+				if g.getRoom(name) == nil {
+					t.Errorf("%v: %v still links to %v", test.name, room.Roomname, name)
+				}
+			}
+		}
+	}
+}
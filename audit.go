@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// AuditScenario is one of the runs audit.md asks for
+type AuditScenario struct {
+	File     string
+	MaxTurns int           // most turns the audit accepts, 0 when it doesn't count them
+	MaxTime  time.Duration // longest the audit waits for the answer
+	Invalid  bool          // the colony has to be rejected with "ERROR: invalid data format"
+}
+
+// AuditScenarios are the scenarios of audit.md
+var AuditScenarios = []AuditScenario{
+	{File: "example00.txt", MaxTurns: 6, MaxTime: 10 * time.Second},
+	{File: "example01.txt", MaxTurns: 8, MaxTime: 10 * time.Second},
+	{File: "example02.txt", MaxTurns: 11, MaxTime: 10 * time.Second},
+	{File: "example03.txt", MaxTurns: 6, MaxTime: 10 * time.Second},
+	{File: "example04.txt", MaxTurns: 6, MaxTime: 10 * time.Second},
+	{File: "example05.txt", MaxTurns: 8, MaxTime: 10 * time.Second},
+	{File: "example06.txt", MaxTime: 90 * time.Second},
+	{File: "example07.txt", MaxTime: 150 * time.Second},
+	{File: "badexample00.txt", MaxTime: 10 * time.Second, Invalid: true},
+	{File: "badexample01.txt", MaxTime: 10 * time.Second, Invalid: true},
+}
+
+// AuditResult is the outcome of one scenario
+type AuditResult struct {
+	Scenario AuditScenario
+	Turns    int
+	Duration time.Duration
+	Failure  string // empty when the scenario passed
+}
+
+var moveLine = regexp.MustCompile(`^L[0-9]+-[^ ]+( L[0-9]+-[^ ]+)*$`)
+
+// RunAuditScenario solves the colony of a scenario and checks the answer the way the audit does
+func RunAuditScenario(dir string, scenario AuditScenario) AuditResult {
+	result := AuditResult{Scenario: scenario}
+	data, err := os.ReadFile(filepath.Join(dir, scenario.File))
+	if err != nil {
+		result.Failure = err.Error()
+		return result
+	}
+
+	// a scenario that runs out of time has its solve cancelled and waited for, so it can't slow down the next scenarios
+	ctx, cancel := context.WithTimeout(context.Background(), scenario.MaxTime)
+	defer cancel()
+	var output bytes.Buffer
+	done := make(chan error, 1)
+	started := time.Now()
+	go func() {
+		solved, err := SolveColony(ctx, bytes.NewReader(data), ParseOptions{}, nil)
+		if err == nil {
+			err = solved.WriteOutput(&output)
+		}
+		done <- err
+	}()
+	select {
+	case err = <-done:
+		result.Duration = time.Since(started)
+	case <-ctx.Done():
+		<-done
+		result.Duration = scenario.MaxTime
+		result.Failure = fmt.Sprintf("no answer within %v", scenario.MaxTime)
+		return result
+	}
+
+	if scenario.Invalid {
+		if err == nil {
+			result.Failure = "the colony was solved instead of rejected"
+		} else if !strings.HasPrefix(err.Error(), "ERROR: invalid data format") {
+			result.Failure = fmt.Sprintf("got %q instead of ERROR: invalid data format", err)
+		}
+		return result
+	}
+	if err != nil {
+		result.Failure = err.Error()
+		return result
+	}
+
+	moves, err := checkOutputFormat(string(data), output.String())
+	if err != nil {
+		result.Failure = err.Error()
+		return result
+	}
+	result.Turns = len(moves)
+	g, _, err := ParseGraph(bytes.NewReader(data))
+	if err == nil {
+		err = Verify(g, moves)
+	}
+	switch {
+	case err != nil:
+		result.Failure = err.Error()
+	case scenario.MaxTurns > 0 && result.Turns > scenario.MaxTurns:
+		result.Failure = fmt.Sprintf("%d turns, the audit allows at most %d", result.Turns, scenario.MaxTurns)
+	}
+	return result
+}
+
+// checkOutputFormat checks that the output is the colony, an empty line and one line of Lx-y moves per turn, and returns the moves
+func checkOutputFormat(colony, output string) ([]string, error) {
+	colony = strings.ReplaceAll(colony, "\r", "")
+	if !strings.HasPrefix(output, colony+"\n\n") {
+		return nil, errors.New("the output doesn't start with the colony followed by an empty line")
+	}
+	moves := strings.Split(strings.TrimSuffix(strings.TrimPrefix(output, colony+"\n\n"), "\n"), "\n")
+	for i, line := range moves {
+		if !moveLine.MatchString(line) {
+			return nil, fmt.Errorf("turn %d: %q is not a line of Lx-y moves", i+1, line)
+		}
+	}
+	return moves, nil
+}
+
+// junitSuite and junitCase are the parts of the JUnit XML format CI servers read
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name    string        `xml:"name,attr"`
+	Time    string        `xml:"time,attr"`
+	Failure *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit writes the results as a JUnit XML test suite
+func WriteJUnit(w io.Writer, results []AuditResult) error {
+	suite := junitSuite{Name: "lem-in audit", Tests: len(results)}
+	var total time.Duration
+	for _, result := range results {
+		total += result.Duration
+		c := junitCase{Name: result.Scenario.File, Time: fmt.Sprintf("%.3f", result.Duration.Seconds())}
+		if result.Failure != "" {
+			suite.Failures++
+			c.Failure = &junitFailure{Message: result.Failure}
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	suite.Time = fmt.Sprintf("%.3f", total.Seconds())
+	fmt.Fprint(w, xml.Header)
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suite); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// WriteAuditReport writes one PASS or FAIL line per result and a total
+func WriteAuditReport(w io.Writer, results []AuditResult) {
+	passed := 0
+	for _, result := range results {
+		if result.Failure != "" {
+			fmt.Fprintf(w, "FAIL %v: %v\n", result.Scenario.File, result.Failure)
+			continue
+		}
+		passed++
+		if result.Scenario.Invalid {
+			fmt.Fprintf(w, "PASS %v (rejected, %v)\n", result.Scenario.File, result.Duration.Round(time.Microsecond))
+		} else {
+			fmt.Fprintf(w, "PASS %v (%d turns, %v)\n", result.Scenario.File, result.Turns, result.Duration.Round(time.Microsecond))
+		}
+	}
+	fmt.Fprintf(w, "%d/%d scenarios passed\n", passed, len(results))
+}
+
+// RunAudit runs every audit scenario and returns the exit code: 1 when a scenario failed
+func RunAudit(args []string) int {
+	flags := newFlagSet("audit", "[--junit] [--dir directory]", "Solves every example of the audit and checks the output format, the rules and the turn and time limits.")
+	dir := flags.String("dir", ".", "directory holding the example files")
+	junit := flags.Bool("junit", false, "write the report as JUnit XML")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	results := []AuditResult{}
+	failed := false
+	for _, scenario := range AuditScenarios {
+		result := RunAuditScenario(*dir, scenario)
+		failed = failed || result.Failure != ""
+		results = append(results, result)
+	}
+	if *junit {
+		if err := WriteJUnit(os.Stdout, results); err != nil {
+			fmt.Println(err)
+			return ExitInternal
+		}
+	} else {
+		WriteAuditReport(os.Stdout, results)
+	}
+	if failed {
+		return ExitInvalid
+	}
+	return ExitOK
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditScenarios(t *testing.T) {
+	for _, scenario := range AuditScenarios {
+		if result := RunAuditScenario(".", scenario); result.Failure != "" {
+			t.Errorf("%v: %v", scenario.File, result.Failure)
+		}
+	}
+}
+
+func TestAuditScenarioTimeout(t *testing.T) {
+	// a large random colony the path search can't finish in time
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "slow.txt"), []byte(strings.Join(GenerateColony(10, 300, 150, 1), "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	began := time.Now()
+	result := RunAuditScenario(dir, AuditScenario{File: "slow.txt", MaxTime: 200 * time.Millisecond})
+	if !strings.HasPrefix(result.Failure, "no answer within") {
+		t.Errorf("got %q instead of a timeout", result.Failure)
+	}
+	// the solve was stopped, not left running while the next scenario is timed
+	if elapsed := time.Since(began); elapsed > 2*time.Second {
+		t.Errorf("the scenario returned after %v", elapsed)
+	}
+}
+
+func TestCheckOutputFormat(t *testing.T) {
+	colony := "1\n##start\ns 0 0\n##end\ne 1 0\ns-e"
+	outputs := map[string]bool{
+		colony + "\n\nL1-e\n":     true,
+		colony + "\nL1-e\n":       false,
+		colony + "\n\nL1 e\n":     false,
+		colony + "\n\nL1-e  L2\n": false,
+		"1\n\nL1-e\n":             false,
+	}
+	for output, valid := range outputs {
+		if _, err := checkOutputFormat(colony, output); (err == nil) != valid {
+			t.Errorf("checkOutputFormat(%q) = %v, want valid %v", output, err, valid)
+		}
+	}
+}
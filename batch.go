@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The status of a map solved in a batch
+const (
+	BatchSolved  = "solved"  // the map was solved
+	BatchInvalid = "invalid" // the map was rejected, or has no path from ##start to ##end
+	BatchFailed  = "failed"  // the map couldn't be read or the solver failed
+)
+
+// BatchResult is the outcome of one map of a batch
+type BatchResult struct {
+	File     string        `json:"file"`
+	Status   string        `json:"status"`
+	Ants     int           `json:"ants,omitempty"`
+	Turns    int           `json:"turns,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+	Error    string        `json:"error,omitempty"`
+	Code     int           `json:"exit_code"` // the exit code solve gives for the map on its own
+}
+
+// BatchSummary adds up the results of a batch
+type BatchSummary struct {
+	Maps     int           `json:"maps"`
+	Solved   int           `json:"solved"`
+	Invalid  int           `json:"invalid"`
+	Failed   int           `json:"failed"`
+	Turns    int           `json:"turns"`
+	Duration time.Duration `json:"duration_ns"` // time taken by the whole batch, not the sum of the maps
+}
+
+// isBatchPattern checks if an argument names several maps: a directory or a glob pattern
+func isBatchPattern(arg string) bool {
+	if strings.ContainsAny(arg, "*?[") {
+		return true
+	}
+	info, err := os.Stat(arg)
+	return err == nil && info.IsDir()
+}
+
+// ExpandBatch turns files, directories and glob patterns into the sorted list of maps they name.
+// A directory stands for the .txt files directly inside it
+func ExpandBatch(args []string) ([]string, error) {
+	seen := map[string]bool{}
+	files := []string{}
+	for _, arg := range args {
+		matches := []string{arg}
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			arg = filepath.Join(arg, "*.txt")
+		}
+		if strings.ContainsAny(arg, "*?[") {
+			var err error
+			if matches, err = filepath.Glob(arg); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %v", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no map matches %v", arg)
+			}
+		}
+		for _, file := range matches {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// SolveFile solves one map of a batch, read with the parse options, with the solution cache unless it is nil
+func SolveFile(file string, cache *Cache, options ParseOptions) (result BatchResult) {
+	result.File = file
+	started := time.Now()
+	defer func() { result.Duration = time.Since(started) }()
+
+	input, err := os.Open(file)
+	if err != nil {
+		result.Status, result.Error, result.Code = BatchFailed, err.Error(), ExitUsage
+		return result
+	}
+	defer input.Close()
+	options.DiscardLines = true
+	solved, err := ReadColony(input, options)
+	if err == nil {
+		solved.Solution, _, err = SolveCached(context.Background(), solved.Graph, cache)
+	}
+	if err != nil {
+		result.Status, result.Error, result.Code = BatchFailed, err.Error(), ExitCode(err)
+		if result.Code == ExitInvalid || result.Code == ExitNoPath {
+			result.Status = BatchInvalid
+		}
+		return result
+	}
+	solution := solved.Solution
+	result.Status, result.Ants, result.Turns = BatchSolved, solved.Graph.Ants, solution.Turns
+	return result
+}
+
+// SolveBatch solves the maps with a pool of workers, the results are in the order of the files
+func SolveBatch(files []string, workers int, cache *Cache, options ParseOptions) ([]BatchResult, BatchSummary) {
+	if workers < 1 {
+		workers = 1
+	}
+	started := time.Now()
+	results := make([]BatchResult, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				results[job] = SolveFile(files[job], cache, options)
+			}
+		}()
+	}
+	for job := range files {
+		jobs <- job
+	}
+	close(jobs)
+	wg.Wait()
+
+	summary := BatchSummary{Maps: len(results), Duration: time.Since(started)}
+	for _, result := range results {
+		switch result.Status {
+		case BatchSolved:
+			summary.Solved++
+			summary.Turns += result.Turns
+		case BatchInvalid:
+			summary.Invalid++
+		default:
+			summary.Failed++
+		}
+	}
+	return results, summary
+}
+
+// WriteBatchReport prints a line per map and the summary of the batch
+func WriteBatchReport(w io.Writer, results []BatchResult, summary BatchSummary) {
+	for _, result := range results {
+		duration := result.Duration.Round(time.Microsecond)
+		if result.Status == BatchSolved {
+			fmt.Fprintf(w, "%v: %v, %d ants in %d turns (%v)\n", result.File, result.Status, result.Ants, result.Turns, duration)
+		} else {
+			fmt.Fprintf(w, "%v: %v, %v (%v)\n", result.File, result.Status, result.Error, duration)
+		}
+	}
+	fmt.Fprintf(w, "%d maps: %d solved, %d invalid, %d failed, %d turns in total (%v)\n",
+		summary.Maps, summary.Solved, summary.Invalid, summary.Failed, summary.Turns, summary.Duration.Round(time.Microsecond))
+}
+
+// RunBatch solves every map named by the arguments and returns the exit code: the one solve gives for the first map
+// that wasn't solved, 0 when every map was
+func RunBatch(args []string) int {
+	flags := newFlagSet("batch", "[flags] <file|directory|glob>...", "Solves many colonies with a pool of workers and prints a line per colony and a summary.")
+	workers := flags.Int("workers", runtime.NumCPU(), "number of maps solved at the same time")
+	asJSON := flags.Bool("json", false, "print the results and the summary as JSON")
+	cacheOptions := addCacheFlags(flags)
+	parseOptions := addParseOptionFlags(flags)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return ExitUsage
+	}
+	options, err := parseOptions.Options()
+	if err != nil {
+		fmt.Println(err)
+		return ExitUsage
+	}
+	files, err := ExpandBatch(flags.Args())
+	if err != nil {
+		fmt.Println(err)
+		return ExitUsage
+	}
+	cache := cacheOptions.Cache()
+
+	results, summary := SolveBatch(files, *workers, cache, options)
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		report := struct {
+			Results []BatchResult `json:"results"`
+			Summary BatchSummary  `json:"summary"`
+		}{results, summary}
+		if err := encoder.Encode(report); err != nil {
+			fmt.Println(err)
+			return ExitInternal
+		}
+	} else {
+		WriteBatchReport(os.Stdout, results, summary)
+	}
+	for _, result := range results {
+		if result.Code != ExitOK {
+			return result.Code
+		}
+	}
+	return ExitOK
+}
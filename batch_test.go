@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSolveBatch(t *testing.T) {
+	files, err := ExpandBatch([]string{"example0[0-3].txt", "badexample*.txt", "example00.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "badexample00.txt badexample01.txt example00.txt example01.txt example02.txt example03.txt"
+	if got := strings.Join(files, " "); got != want {
+		t.Fatalf("got maps %v, want %v", got, want)
+	}
+	if _, err := ExpandBatch([]string{"nothing*.txt"}); err == nil {
+		t.Errorf("a pattern matching no map was accepted")
+	}
+
+	results, summary := SolveBatch(append(files, "missing.txt"), 3, nil, ParseOptions{})
+	statuses := []string{BatchInvalid, BatchInvalid, BatchSolved, BatchSolved, BatchSolved, BatchSolved, BatchFailed}
+	turns := []int{0, 0, 6, 8, 11, 6, 0}
+	codes := []int{ExitInvalid, ExitInvalid, ExitOK, ExitOK, ExitOK, ExitOK, ExitUsage}
+	for i, result := range results {
+		if result.Status != statuses[i] || result.Turns != turns[i] || result.Code != codes[i] {
+			t.Errorf("%v: got %v in %d turns, exit code %d, want %v in %d turns, exit code %d",
+				result.File, result.Status, result.Turns, result.Code, statuses[i], turns[i], codes[i])
+		}
+	}
+	if summary.Maps != 7 || summary.Solved != 4 || summary.Invalid != 2 || summary.Failed != 1 || summary.Turns != 31 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
+
+func TestSolveSeveralMaps(t *testing.T) {
+	cacheDir := t.TempDir()
+	noPath := filepath.Join(t.TempDir(), "nopath.txt")
+	if err := os.WriteFile(noPath, []byte("1\n##start\ns 0 0\na 1 0\n##end\ne 2 0\nb 3 0\ns-a\ne-b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		args   []string
+		code   int
+		output string // start of what is printed
+	}{
+		{[]string{"example00.txt", "example01.txt"}, ExitOK, "example00.txt: solved, 4 ants in 6 turns"},
+		{[]string{"--json", "example00.txt", "example01.txt"}, ExitOK, "{\n  \"results\": ["},
+		{[]string{"--order", "ant", "--quiet", "example00.txt", "example01.txt"}, ExitOK, "example00.txt: solved"},
+		{[]string{"--cache-dir", cacheDir, "example0[01].txt"}, ExitOK, "example00.txt: solved"},
+		// the exit code is the one solve gives for the first map, in sorted order, that wasn't solved
+		{[]string{"example00.txt", "badexample00.txt"}, ExitInvalid, "badexample00.txt: invalid"},
+		{[]string{"badexample00.txt", noPath}, ExitNoPath, noPath + ": invalid"},
+		{[]string{"example00.txt", "missing.txt"}, ExitUsage, "example00.txt: solved"},
+		{[]string{"--by-ant", "example00.txt", "example01.txt"}, ExitUsage, "--by-ant can only be used with a single map"},
+		{[]string{"--order", "room", "--summary", "stderr", "example00.txt", "example01.txt"}, ExitUsage, "--order, --summary can only be used"},
+		{[]string{"--bottlenecks", "example0*.txt"}, ExitUsage, "--bottlenecks can only be used"},
+	} {
+		stdout, _, code := run(t, append([]string{"solve"}, test.args...)...)
+		if code != test.code || !strings.HasPrefix(stdout, test.output) {
+			t.Errorf("%v: got exit code %d and\n%v\nwant %d and %q", test.args, code, stdout, test.code, test.output)
+		}
+	}
+	// the batch stored the solutions in the cache it was given
+	if entries, err := os.ReadDir(cacheDir); err != nil || len(entries) != 2 {
+		t.Errorf("the cache holds %d solutions, %v", len(entries), err)
+	}
+}
+
+func TestSolvesOverlap(t *testing.T) {
+	// the path search of a large colony takes far longer than the test, it runs until it is cancelled
+	slow, _, err := ParseGraph(strings.NewReader(strings.Join(GenerateColony(10, 300, 150, 1), "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	small, _, err := ParseGraph(strings.NewReader("2\n##start\ns 0 0\na 1 0\nb 1 1\n##end\ne 2 0\ns-a\ns-b\na-e\nb-e"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	slowDone := make(chan error, 1)
+	go func() {
+		_, err := SolveContext(ctx, slow)
+		slowDone <- err
+	}()
+	defer func() {
+		cancel()
+		<-slowDone
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// a second solve finishes while the first one is still searching, instead of waiting for it
+	smallDone := make(chan error, 1)
+	go func() {
+		_, err := SolveContext(context.Background(), small)
+		smallDone <- err
+	}()
+	select {
+	case err := <-smallDone:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the second solve waited for the first one")
+	}
+	select {
+	case err := <-slowDone:
+		t.Fatalf("the large colony was solved before the test could overlap the solves: %v", err)
+	default:
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Block closes a room, or the tunnel between two rooms, from the given turn onward
+type Block struct {
+	Turn int    // first turn during which the room or tunnel is closed
+	Room string // closed room, empty when a tunnel is closed
+	From string // one end of the closed tunnel
+	To   string // other end of the closed tunnel
+}
+
+// String turns the block back into its "##block T room" or "##block T room1-room2" directive
+func (b Block) String() string {
+	if b.Room != "" {
+		return fmt.Sprintf("##block %d %v", b.Turn, b.Room)
+	}
+	return fmt.Sprintf("##block %d %v-%v", b.Turn, b.From, b.To)
+}
+
+// antState keeps track of where an ant is and which rooms it still has to walk through
+type antState struct {
+	id    int
+	room  string
+	route []string
+}
+
+// ParseBlock converts a "##block T room" or "##block T room1-room2" line to a Block
+func ParseBlock(line string) (Block, error) {
+	words := strings.Fields(line)
+	if len(words) != 3 {
+		return Block{}, invalidError{"ERROR: invalid data format. A ##block needs a turn and a room or tunnel"}
+	}
+	turn, err := strconv.Atoi(words[1])
+	if err != nil || turn < 1 {
+		return Block{}, invalidError{"ERROR: invalid data format. The turn of a ##block must be a positive number"}
+	}
+	rooms := strings.Split(words[2], "-")
+	switch len(rooms) {
+	case 1:
+		return Block{Turn: turn, Room: rooms[0]}, nil
+	case 2:
+		return Block{Turn: turn, From: rooms[0], To: rooms[1]}, nil
+	}
+	return Block{}, invalidError{"ERROR: invalid data format. A ##block tunnel joins exactly two rooms"}
+}
+
+// checkBlocks checks that every block refers to rooms of the graph and never closes the start or end room
+func checkBlocks(g *Graph) error {
+	if len(g.Blocks) > 0 && len(g.Checkpoints) > 0 {
+		return invalidError{"ERROR: invalid data format. ##block can't be combined with ##checkpoint"}
+	}
+	for _, block := range g.Blocks {
+		if block.Room == g.StartRoomName || block.Room == g.EndRoomName {
+			return invalidError{"ERROR: invalid data format. The start and end rooms can't be blocked"}
+		}
+		for _, name := range []string{block.Room, block.From, block.To} {
+			if name != "" && g.getRoom(name) == nil {
+				return invalidError{fmt.Sprintf("ERROR: invalid data format. Blocked room doesn't exist (%v)", name)}
+			}
+		}
+	}
+	return nil
+}
+
+// tunnelKey names a tunnel the same way whichever direction it is walked in
+func tunnelKey(from, to string) string {
+	if from > to {
+		from, to = to, from
+	}
+	return from + "-" + to
+}
+
+// ScheduleWithBlocks moves the ants along the given paths turn by turn, and re-plans the remaining ants
+// on the current graph every time a room or tunnel closes. No ant is ever moved into a closed room or through a closed tunnel
+func ScheduleWithBlocks(g *Graph, paths []string) ([]string, error) {
+	ants := make([]*antState, g.Ants)
+	for i := range ants {
+		ants[i] = &antState{id: i + 1, room: g.StartRoomName}
+	}
+	assignRoutes(ants, paths)
+
+	closedRooms := map[string]bool{}
+	closedTunnels := map[string]bool{}
+	occupied := map[string]bool{}
+	arrived := 0
+	steps := []string{}
+
+	for turn := 1; arrived < len(ants); turn++ {
+		changed := false
+		for _, block := range g.Blocks {
+			if block.Turn != turn {
+				continue
+			}
+			changed = true
+			if block.Room != "" {
+				closedRooms[block.Room] = true
+			} else {
+				closedTunnels[tunnelKey(block.From, block.To)] = true
+			}
+		}
+		if changed {
+			if err := replan(g, ants, closedRooms, closedTunnels); err != nil {
+				return nil, fmt.Errorf("%w (turn %d)", err, turn)
+			}
+		}
+
+		// ants that move first free their room for the ants behind them, so keep going until nobody can move
+		usedTunnels := map[string]bool{}
+		moved := map[int]bool{}
+		moves := []*antState{}
+		for progress := true; progress; {
+			progress = false
+			for _, ant := range ants {
+				if moved[ant.id] || len(ant.route) == 0 {
+					continue
+				}
+				next := ant.route[0]
+				tunnel := tunnelKey(ant.room, next)
+				if closedRooms[next] || closedTunnels[tunnel] || usedTunnels[tunnel] || occupied[next] {
+					continue
+				}
+				delete(occupied, ant.room)
+				if next != g.EndRoomName {
+					occupied[next] = true
+				} else {
+					arrived++
+				}
+				ant.room = next
+				ant.route = ant.route[1:]
+				usedTunnels[tunnel] = true
+				moved[ant.id] = true
+				moves = append(moves, ant)
+				progress = true
+			}
+		}
+		if len(moves) == 0 {
+			return nil, noPathError{fmt.Sprintf("ERROR: the ants are stuck at turn %d", turn)}
+		}
+
+		sort.Slice(moves, func(i, j int) bool {
+			return moves[i].id < moves[j].id
+		})
+		step := make([]string, len(moves))
+		for i, ant := range moves {
+			step[i] = "L" + strconv.Itoa(ant.id) + "-" + ant.room
+		}
+		steps = append(steps, strings.Join(step, " "))
+	}
+	return steps, nil
+}
+
+// assignRoutes spreads the ants over the paths the same way AntSender does
+func assignRoutes(ants []*antState, paths []string) {
+	pathLists := make([][]string, len(paths))
+	for i, path := range paths {
+		pathLists[i] = strings.Split(path, "-")
+	}
+	ids := make([]int, len(ants))
+	for i, ant := range ants {
+		ids[i] = ant.id
+	}
+	byID := map[int]*antState{}
+	for _, ant := range ants {
+		byID[ant.id] = ant
+	}
+	for i, queue := range distributeAnts(ids, pathLists) {
+		for _, id := range queue {
+			byID[id].route = pathLists[i]
+		}
+	}
+}
+
+// replan gives every ant that hasn't arrived a new route through the graph that is still open.
+// Ants on their way take the shortest way left, ants still in the start room are spread over a fresh set of paths
+func replan(g *Graph, ants []*antState, closedRooms, closedTunnels map[string]bool) error {
+	open := openGraph(g, closedRooms, closedTunnels)
+
+	waiting := []*antState{}
+	for _, ant := range ants {
+		switch {
+		case len(ant.route) == 0:
+		case ant.room == g.StartRoomName:
+			waiting = append(waiting, ant)
+		default:
+			route := shortestRoute(open, ant.room)
+			if route == nil {
+				return noPathError{fmt.Sprintf("ERROR: no way left to the end room for ant %d", ant.id)}
+			}
+			ant.route = route
+		}
+	}
+	if len(waiting) == 0 {
+		return nil
+	}
+
+	gdfs, gbfs := DeepCopyGraph(open), DeepCopyGraph(open)
+	allPathsDFS, allPathsBFS := []string{}, []string{}
+	DFS(gdfs.StartRoomName, gdfs.EndRoomName, gdfs, "", &allPathsDFS)
+	BFS(gbfs.StartRoomName, gbfs.EndRoomName, gbfs, &allPathsBFS, ShortestPath)
+	lenSorter(&allPathsDFS)
+	lenSorter(&allPathsBFS)
+	paths := allPathsDFS
+	if len(paths) == 0 || (len(allPathsBFS) > 0 && len(AntSender(len(waiting), allPathsDFS)) > len(AntSender(len(waiting), allPathsBFS))) {
+		paths = allPathsBFS
+	}
+	if len(paths) == 0 {
+		return noPathError{"ERROR: no way left from the start room to the end room"}
+	}
+	assignRoutes(waiting, paths)
+	return nil
+}
+
+// openGraph copies the graph without the closed rooms and tunnels
+func openGraph(g *Graph, closedRooms, closedTunnels map[string]bool) *Graph {
+	open := DeepCopyGraph(g)
+	for _, room := range open.Rooms {
+		connections := []string{}
+		for _, name := range room.Connections {
+			if !closedRooms[name] && !closedTunnels[tunnelKey(room.Roomname, name)] {
+				connections = append(connections, name)
+			}
+		}
+		room.Connections = connections
+	}
+	return open
+}
+
+// shortestRoute finds the shortest way from a room to the end room, leaving out the room itself
+func shortestRoute(g *Graph, from string) []string {
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == g.EndRoomName {
+			route := []string{}
+			for room := current; room != from; room = previous[room] {
+				route = append([]string{room}, route...)
+			}
+			return route
+		}
+		for _, next := range g.getRoom(current).Connections {
+			if _, seen := previous[next]; seen || next == g.StartRoomName {
+				continue
+			}
+			previous[next] = current
+			queue = append(queue, next)
+		}
+	}
+	return nil
+}
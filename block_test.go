@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScheduleWithBlocks(t *testing.T) {
+	// two ways from s to e, through a or through b and c, with a shortcut from a to c
+	colony := "4\n##start\ns 0 0\na 1 0\nb 1 1\nc 2 1\n##end\ne 2 0\ns-a\na-e\ns-b\nb-c\nc-e\na-c\n"
+	for _, test := range []struct {
+		name   string
+		blocks string
+		turns  int
+		err    string // start of the error when the ants can't make it
+	}{
+		{"room closed before the first turn", "##block 1 a", 6, ""},
+		{"room closed with an ant inside", "##block 2 a", 5, ""},
+		{"tunnel closed under an ant", "##block 2 a-e", 6, ""},
+		{"block after every ant arrived", "##block 9 a", 4, ""},
+		{"ant trapped on its way", "##block 2 a-e\n##block 2 c", 0, "ERROR: no way left to the end room for ant 1"},
+		{"start cut off", "##block 2 a\n##block 2 b", 0, "ERROR: no way left from the start room to the end room"},
+	} {
+		g, _, err := ParseGraph(strings.NewReader(colony + test.blocks))
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		solution, err := Solve(g)
+		if test.err != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+				t.Errorf("%v: got %v, want %v", test.name, err, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if !solution.Replanned || (test.turns > 0 && solution.Turns != test.turns) {
+			t.Errorf("%v: %d turns, replanned %v, want %d turns", test.name, solution.Turns, solution.Replanned, test.turns)
+		}
+		if err := Verify(g, solution.Moves()); err != nil {
+			t.Errorf("%v: %v", test.name, err)
+		}
+	}
+}
+
+func TestVerifyBlocks(t *testing.T) {
+	g, _, err := ParseGraph(strings.NewReader("2\n##start\ns 0 0\na 1 0\nb 1 1\n##end\ne 2 0\ns-a\na-e\ns-b\nb-e\n##block 2 a\n##block 3 b-e"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		steps []string
+		err   string
+	}{
+		{[]string{"L1-a L2-b", "L1-e L2-e"}, ""},
+		{[]string{"L1-b", "L1-e L2-a", "L2-e"}, "closed room a"},
+		{[]string{"L1-a", "L1-e L2-b", "L2-e"}, "closed tunnel b-e"},
+	} {
+		err := Verify(g, test.steps)
+		if (err == nil) != (test.err == "") || (err != nil && !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%v: got %v, want %q", test.steps, err, test.err)
+		}
+	}
+}
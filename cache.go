@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SolverVersion is part of every cache key, it has to change whenever the solver can find other paths or schedules
+// for the same colony, so that solutions of an older solver are never read back
+const SolverVersion = "2"
+
+// Cache keeps solutions on disk, one JSON file per colony named by the colony's key
+type Cache struct {
+	Dir string
+}
+
+// cacheEntry is what the cache stores for a colony: the paths, and the moves of every turn when they can't be
+// rebuilt from the paths because rooms or tunnels collapse during the run
+type cacheEntry struct {
+	Version   string   `json:"version"`
+	Ants      int      `json:"ants"`
+	Paths     []string `json:"paths"`
+	Steps     []string `json:"steps,omitempty"`
+	Turns     int      `json:"turns"`
+	Strategy  string   `json:"strategy"`
+	Replanned bool     `json:"replanned,omitempty"`
+	Spacing   int      `json:"spacing,omitempty"`
+}
+
+// CacheKey hashes what the solution of a colony depends on: the ants, the start and end rooms, the rooms, the links,
+// the checkpoints, the blocks and the solver version. The searches break ties by the order of the rooms and of the links
+// of every room, so that order is part of the key and a cached solution is always the one a fresh solve finds.
+// Coordinates and comments don't matter
+func CacheKey(g *Graph) string {
+	rooms := []string{}
+	for _, room := range g.Rooms {
+		rooms = append(rooms, room.Roomname+" "+strings.Join(room.Connections, " "))
+	}
+	blocks := []string{}
+	for _, block := range g.Blocks {
+		blocks = append(blocks, block.String())
+	}
+
+	// every part is on its own line and names never hold a newline, so two different colonies can't give the same text
+	canonical := strings.Join([]string{
+		"solver " + SolverVersion,
+		fmt.Sprint("ants ", g.Ants),
+		"start " + g.StartRoomName,
+		"end " + g.EndRoomName,
+		"rooms " + strings.Join(rooms, "\t"),
+		"checkpoints " + strings.Join(g.Checkpoints, " "),
+		"blocks " + strings.Join(blocks, "\t"),
+	}, "\n")
+	sum := sha256.Sum256([]byte(canonical))
+	return hex.EncodeToString(sum[:])
+}
+
+// path returns the file of a key
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key+".json")
+}
+
+// Get reads the solution of a key, ok is false when the cache doesn't have it or can't read it
+func (c *Cache) Get(key string) (*Solution, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Version != SolverVersion {
+		return nil, false
+	}
+	return &Solution{Ants: entry.Ants, Paths: entry.Paths, Steps: entry.Steps, Turns: entry.Turns, Strategy: entry.Strategy, Replanned: entry.Replanned, Spacing: entry.Spacing}, true
+}
+
+// Put stores the solution of a key. The file is written under another name first and then renamed,
+// so that a solve running at the same time never reads half a file
+func (c *Cache) Put(key string, solution *Solution) error {
+	entry := cacheEntry{Version: SolverVersion, Ants: solution.Ants, Paths: solution.Paths, Turns: solution.Turns, Strategy: solution.Strategy, Replanned: solution.Replanned, Spacing: solution.Spacing}
+	if solution.Replanned {
+		entry.Steps = solution.Steps
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(c.Dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+	return os.Rename(file.Name(), c.path(key))
+}
+
+// Prune removes the solutions that haven't been written for longer than maxAge, or all of them when maxAge is 0,
+// and returns how many were removed
+func (c *Cache) Prune(maxAge time.Duration) (int, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !(strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".tmp")) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if maxAge > 0 && time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.Dir, name)); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// SolveCached returns the solution of the cache when it has one, and solves the graph and stores the solution otherwise.
+// A nil cache just solves the graph. Failing to store the solution doesn't fail the solve
+func SolveCached(ctx context.Context, g *Graph, cache *Cache) (*Solution, bool, error) {
+	if cache == nil {
+		solution, err := SolveContext(ctx, g)
+		return solution, false, err
+	}
+	key := CacheKey(g)
+	if solution, ok := cache.Get(key); ok {
+		return solution, true, nil
+	}
+	solution, err := SolveContext(ctx, g)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := cache.Put(key, solution); err != nil {
+		fmt.Fprintln(os.Stderr, "the solution couldn't be cached:", err)
+	}
+	return solution, false, nil
+}
+
+// defaultCacheDir is $LEMIN_CACHE_DIR, or lemin in the user's cache directory
+func defaultCacheDir() string {
+	if dir := os.Getenv("LEMIN_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "lemin")
+	}
+	return filepath.Join(os.TempDir(), "lemin-cache")
+}
+
+// cacheFlags are the flags of the commands that can use the cache
+type cacheFlags struct {
+	enable  *bool
+	disable *bool
+	dir     *string
+}
+
+// addCacheFlags adds --cache, --no-cache and --cache-dir to a command
+func addCacheFlags(flags *flag.FlagSet) *cacheFlags {
+	return &cacheFlags{
+		enable:  flags.Bool("cache", false, "read and store solutions in the cache, also turned on by setting $LEMIN_CACHE_DIR"),
+		disable: flags.Bool("no-cache", false, "neither read nor store solutions in the cache, even when $LEMIN_CACHE_DIR is set"),
+		dir:     flags.String("cache-dir", "", "directory of the cache, $LEMIN_CACHE_DIR or lemin in the user's cache directory by default"),
+	}
+}
+
+// Cache returns the cache the flags ask for, nil when it is off
+func (f *cacheFlags) Cache() *Cache {
+	if *f.disable || !(*f.enable || *f.dir != "" || os.Getenv("LEMIN_CACHE_DIR") != "") {
+		return nil
+	}
+	if *f.dir != "" {
+		return &Cache{Dir: *f.dir}
+	}
+	return &Cache{Dir: defaultCacheDir()}
+}
+
+// RunCachePrune empties the cache, or removes the solutions older than --older-than, and returns the exit code
+func RunCachePrune(args []string) int {
+	flags := newFlagSet("cache-prune", "[flags]", "Removes the solutions stored by --cache.")
+	dir := flags.String("cache-dir", "", "directory of the cache, $LEMIN_CACHE_DIR or lemin in the user's cache directory by default")
+	olderThan := flags.Duration("older-than", 0, "only remove the solutions stored longer ago than this, for example 720h")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() != 0 || *olderThan < 0 {
+		flags.Usage()
+		return ExitUsage
+	}
+	cache := &Cache{Dir: *dir}
+	if cache.Dir == "" {
+		cache.Dir = defaultCacheDir()
+	}
+	removed, err := cache.Prune(*olderThan)
+	if err != nil {
+		fmt.Println(err)
+		return ExitInternal
+	}
+	fmt.Printf("Removed %d solutions from %v\n", removed, cache.Dir)
+	return ExitOK
+}
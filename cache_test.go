@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	parse := func(colony string) *Graph {
+		g, _, err := ParseGraph(strings.NewReader(colony))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return g
+	}
+	colony := "3\n##start\na 0 0\nb 1 1\nc 5 5\n##end\nd 2 2\na-b\nb-d\na-c\nc-d"
+	key := CacheKey(parse(colony))
+
+	// coordinates and comments don't change the key, the ants, the links and the order the searches see them in do
+	same := "3\n# the same colony\n##start\na 3 3\nb 4 4\nc 9 9\n##end\nd 7 7\na-b\nb-d\na-c\nc-d"
+	if other := CacheKey(parse(same)); other != key {
+		t.Errorf("the same colony got another key")
+	}
+	reordered := "3\n##start\na 0 0\nc 5 5\nb 1 1\n##end\nd 2 2\nd-c\nc-a\nb-d\na-b"
+	for _, changed := range []string{strings.Replace(colony, "3", "4", 1), colony + "\nb-c", reordered} {
+		if CacheKey(parse(changed)) == key {
+			t.Errorf("a different colony got the same key:\n%v", changed)
+		}
+	}
+
+	cache := &Cache{Dir: t.TempDir()}
+	solution, hit, err := SolveCached(context.Background(), parse(colony), cache)
+	if err != nil || hit {
+		t.Fatalf("first solve: hit %v, %v", hit, err)
+	}
+	cached, hit, err := SolveCached(context.Background(), parse(same), cache)
+	if err != nil || !hit {
+		t.Fatalf("second solve: hit %v, %v", hit, err)
+	}
+	if strings.Join(cached.Moves(), "\n") != strings.Join(solution.Moves(), "\n") || cached.Turns != solution.Turns {
+		t.Errorf("the cached solution differs:\n%v\nwant\n%v", cached.Moves(), solution.Moves())
+	}
+
+	// the same links in another order lead the searches to other paths, the cache still answers with what a fresh solve finds
+	rooms := "3\n##start\nstart 2 1\nr1 3 2\nr2 1 3\nr3 1 2\nr4 4 2\nr5 1 1\nr6 2 4\n##end\nend 3 3\n"
+	before := rooms + "start-r1\nr1-r2\nr2-r3\nr1-r4\nr3-r5\nstart-r6\nr6-end\nr5-end\nr4-end\nr5-start\nr5-r2\nr1-r6"
+	after := rooms + "r3-r5\nstart-r1\nr1-r4\nr1-r6\nstart-r6\nr2-r3\nr1-r2\nr5-r2\nr6-end\nr5-start\nr5-end\nr4-end"
+	for _, c := range []string{colony, same, reordered, before, after} {
+		fresh, err := Solve(parse(c))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			cached, _, err := SolveCached(context.Background(), parse(c), cache)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(cached.Paths, " ") != strings.Join(fresh.Paths, " ") || strings.Join(cached.Moves(), "\n") != strings.Join(fresh.Moves(), "\n") {
+				t.Errorf("the cache answered\n%v\nfor\n%v\ninstead of\n%v", cached.Moves(), c, fresh.Moves())
+			}
+		}
+	}
+
+	if removed, err := cache.Prune(0); err != nil || removed != 4 {
+		t.Errorf("prune removed %d solutions, %v", removed, err)
+	}
+	if entries, _ := os.ReadDir(cache.Dir); len(entries) != 0 {
+		t.Errorf("%d files are left in the cache", len(entries))
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxCheckpoints is the most checkpoints a colony can have, the search keeps the checkpoints a route has passed
+// as the bits of an int
+const MaxCheckpoints = 63
+
+// checkCheckpoints checks that the search can keep track of every checkpoint of the colony
+func checkCheckpoints(g *Graph) error {
+	if len(g.Checkpoints) > MaxCheckpoints {
+		return invalidError{fmt.Sprintf("ERROR: invalid data format. %d ##checkpoint rooms, at most %d are allowed", len(g.Checkpoints), MaxCheckpoints)}
+	}
+	return nil
+}
+
+// checkpointState is a room reached by the search together with the checkpoints passed on the way there
+type checkpointState struct {
+	room    string
+	visited int // bit i is set once checkpoint i has been passed
+}
+
+// CheckpointPath finds the shortest route from the start room to the end room that passes through every checkpoint room.
+// The search is a BFS over the rooms paired with the set of checkpoints already passed, so it takes time proportional to
+// the rooms and links of the graph for every combination of checkpoints instead of trying every path. The shortest route
+// can pass a room twice, to reach a checkpoint in a dead end; RouteSpacing tells how far apart the ants then have to leave.
+// The route is returned in the same "room-room-end" format as the DFS and BFS searches produce
+func CheckpointPath(g *Graph) (string, error) {
+	if err := checkCheckpoints(g); err != nil {
+		return "", err
+	}
+	bits := map[string]int{}
+	for _, checkpoint := range g.Checkpoints {
+		if checkpoint == g.StartRoomName || checkpoint == g.EndRoomName {
+			return "", invalidError{"ERROR: invalid data format. The start and end rooms can't be checkpoints"}
+		}
+		if _, ok := bits[checkpoint]; !ok {
+			bits[checkpoint] = 1 << len(bits)
+		}
+	}
+	all := 1<<len(bits) - 1
+
+	first := checkpointState{g.StartRoomName, 0}
+	previous := map[checkpointState]checkpointState{first: first}
+	queue := []checkpointState{first}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current.room == g.EndRoomName {
+			if current.visited != all {
+				continue
+			}
+			route := []string{}
+			for state := current; state != first; state = previous[state] {
+				route = append([]string{state.room}, route...)
+			}
+			return strings.Join(route, "-"), nil
+		}
+		for _, next := range g.getRoom(current.room).Connections {
+			if next == "" || next == g.StartRoomName {
+				continue
+			}
+			state := checkpointState{next, current.visited | bits[next]}
+			if _, seen := previous[state]; !seen {
+				previous[state] = current
+				queue = append(queue, state)
+			}
+		}
+	}
+	return "", noPathError{"ERROR: no path from ##start to ##end passes through every ##checkpoint"}
+}
+
+// RouteSpacing returns how many turns apart the ants have to leave to follow a route, given without its start room.
+// On a route that passes no room or tunnel twice that is every turn. Otherwise an ant leaving right behind another would
+// meet it, so the ants leave one more turn apart than the longest stretch between two visits of the same room or tunnel
+func RouteSpacing(route string) int {
+	rooms := strings.Split(route, "-")
+	firstSeen := map[string]int{}
+	spacing := 1
+	from := ""
+	for i, room := range rooms {
+		for _, key := range []string{room, tunnelKey(from, room)} {
+			if first, ok := firstSeen[key]; ok && i-first+1 > spacing {
+				spacing = i - first + 1
+			} else if !ok {
+				firstSeen[key] = i
+			}
+		}
+		from = room
+	}
+	return spacing
+}
+
+// SpacedMoves sends n ants along a single route, leaving spacing turns apart, and returns the moves of every turn
+func SpacedMoves(n int, route string, spacing int) []string {
+	rooms := strings.Split(route, "-")
+	steps := []string{}
+	for turn := 0; turn < (n-1)*spacing+len(rooms); turn++ {
+		moves := []string{}
+		for ant := 1; ant <= n; ant++ {
+			if room := turn - (ant-1)*spacing; room >= 0 && room < len(rooms) {
+				moves = append(moves, "L"+strconv.Itoa(ant)+"-"+rooms[room])
+			}
+		}
+		steps = append(steps, strings.Join(moves, " "))
+	}
+	return steps
+}
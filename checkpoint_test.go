@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// grid builds a colony of size by size rooms linked to their neighbours, from ##start in one corner to ##end in the other
+func grid(ants, size int, checkpoints ...string) string {
+	lines := []string{fmt.Sprint(ants)}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			name := fmt.Sprintf("r%d_%d", x, y)
+			switch {
+			case x == 0 && y == 0:
+				lines = append(lines, "##start")
+			case x == size-1 && y == size-1:
+				lines = append(lines, "##end")
+			case contains(checkpoints, name):
+				lines = append(lines, "##checkpoint")
+			}
+			lines = append(lines, fmt.Sprintf("%v %d %d", name, x, y))
+		}
+	}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if x+1 < size {
+				lines = append(lines, fmt.Sprintf("r%d_%d-r%d_%d", x, y, x+1, y))
+			}
+			if y+1 < size {
+				lines = append(lines, fmt.Sprintf("r%d_%d-r%d_%d", x, y, x, y+1))
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestCheckpointPath(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		colony  string
+		route   string // empty when no route passes every checkpoint
+		spacing int
+		turns   int
+	}{
+		{"on the way", "2\n##start\ns 0 0\n##checkpoint\na 1 0\nb 1 1\n##end\ne 2 0\ns-a\ns-b\na-e\nb-e", "a-e", 1, 3},
+		{"detour", "2\n##start\ns 0 0\na 1 0\n##checkpoint\nb 1 1\nc 2 2\n##end\ne 2 0\ns-a\na-e\ns-b\nb-c\nc-e", "b-c-e", 1, 4},
+		{"dead end", "3\n##start\ns 0 0\na 1 0\n##checkpoint\nc 1 1\n##end\ne 2 0\ns-a\na-c\na-e", "a-c-a-e", 3, 10},
+		{"two checkpoints", "1\n##start\ns 0 0\n##checkpoint\na 1 0\n##checkpoint\nb 1 1\n##end\ne 2 0\ns-a\na-e\ns-b\nb-e\na-b", "a-b-e", 1, 3},
+		{"checkpoint cut off", "1\n##start\ns 0 0\n##checkpoint\na 1 0\nb 1 1\n##end\ne 2 0\ns-b\nb-e\na-b", "b-a-b-e", 3, 4},
+		{"no route", "1\n##start\ns 0 0\nb 1 1\n##checkpoint\nc 5 5\n##end\ne 2 0\ns-b\nb-e\ne-c", "", 0, 0},
+		{"large grid", grid(5, 12, "r11_0", "r0_11", "r6_6"), "", 0, 0},
+	} {
+		g, _, err := ParseGraph(strings.NewReader(test.colony))
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		began := time.Now()
+		solution, err := Solve(g)
+		if elapsed := time.Since(began); elapsed > 5*time.Second {
+			t.Errorf("%v: took %v", test.name, elapsed)
+		}
+		if test.name == "no route" {
+			if ExitCode(err) != ExitNoPath {
+				t.Errorf("%v: got %v instead of a no path error", test.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		if test.route != "" && (solution.Paths[0] != test.route || RouteSpacing(solution.Paths[0]) != test.spacing || solution.Turns != test.turns) {
+			t.Errorf("%v: route %v with spacing %d in %d turns, want %v with spacing %d in %d turns",
+				test.name, solution.Paths[0], RouteSpacing(solution.Paths[0]), solution.Turns, test.route, test.spacing, test.turns)
+		}
+		if err := Verify(g, solution.Moves()); err != nil {
+			t.Errorf("%v: %v", test.name, err)
+		}
+		if len(solution.Moves()) != solution.Turns {
+			t.Errorf("%v: %d turns of moves for %d turns", test.name, len(solution.Moves()), solution.Turns)
+		}
+	}
+}
+
+func TestVerifyCheckpoints(t *testing.T) {
+	g, _, err := ParseGraph(strings.NewReader("2\n##start\ns 0 0\n##checkpoint\na 1 0\nb 1 1\n##end\ne 2 0\ns-a\ns-b\na-e\nb-e"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(g, []string{"L1-a L2-b", "L1-e L2-e"}); err == nil || !strings.Contains(err.Error(), "checkpoint a") {
+		t.Errorf("an ant skipping the checkpoint was accepted: %v", err)
+	}
+	if err := Verify(g, []string{"L1-a", "L1-e L2-a", "L2-e"}); err != nil {
+		t.Errorf("legal moves were rejected: %v", err)
+	}
+}
+
+func TestCheckpointLimit(t *testing.T) {
+	// a corridor from the start room to the end room where every room is a checkpoint
+	corridor := func(checkpoints int) string {
+		lines := []string{"1", "##start", "s 0 0", "##end", "e 0 1"}
+		previous := "s"
+		for i := 0; i < checkpoints; i++ {
+			lines = append(lines, "##checkpoint", fmt.Sprintf("c%d %d 2", i, i))
+		}
+		for i := 0; i < checkpoints; i++ {
+			lines = append(lines, fmt.Sprintf("%v-c%d", previous, i))
+			previous = fmt.Sprintf("c%d", i)
+		}
+		return strings.Join(append(lines, previous+"-e"), "\n")
+	}
+	for _, test := range []struct {
+		checkpoints int
+		code        int
+	}{
+		{MaxCheckpoints, ExitOK},
+		{MaxCheckpoints + 1, ExitInvalid},
+	} {
+		g, _, err := ParseGraph(strings.NewReader(corridor(test.checkpoints)))
+		if err == nil {
+			var solution *Solution
+			if solution, err = Solve(g); err == nil && solution.Turns != test.checkpoints+1 {
+				t.Errorf("%d checkpoints: %d turns, want %d", test.checkpoints, solution.Turns, test.checkpoints+1)
+			}
+		}
+		if code := ExitCode(err); code != test.code {
+			t.Errorf("%d checkpoints: got exit code %d for %v, want %d", test.checkpoints, code, err, test.code)
+		}
+	}
+	// a graph built without the parser is checked by the search itself
+	g := &Graph{StartRoomName: "s", EndRoomName: "e", Checkpoints: make([]string, MaxCheckpoints+1)}
+	if _, err := CheckpointPath(g); !errors.Is(err, ErrInvalid) {
+		t.Errorf("CheckpointPath accepted %d checkpoints: %v", MaxCheckpoints+1, err)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// The exit codes of every command
+const (
+	ExitOK       = 0
+	ExitInvalid  = 1 // the input is invalid: a rejected colony, lint errors, illegal moves, a failed audit
+	ExitUsage    = 2 // the command line is wrong or a file can't be read
+	ExitNoPath   = 3 // the colony is valid, but no path leads the ants from ##start to ##end
+	ExitInternal = 4 // the solver or the output failed
+)
+
+// subcommand is a command of the command line, like lint in "go run . lint example00.txt"
+type subcommand struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+// subcommands lists the commands in the order the usage shows them
+var subcommands = []subcommand{
+	{"solve", "solve a colony, the command used when none is given", RunSolve},
+	{"validate", "check that a colony can be solved", RunValidate},
+	{"verify", "check that the output of solve follows the rules", RunVerify},
+	{"lint", "list every problem of a colony", RunLint},
+	{"fmt", "rewrite a colony in the canonical layout", RunFormat},
+	{"stats", "print statistics about a colony", RunStats},
+	{"generate", "write a random colony", RunGenerate},
+	{"batch", "solve many colonies at once", RunBatch},
+	{"audit", "run the audit scenarios", RunAudit},
+	{"cache-prune", "remove solutions from the cache", RunCachePrune},
+	{"serve", "answer solve, validate and verify requests over HTTP", RunServe},
+}
+
+// RunCommand runs the command named by the first argument and returns the exit code. Without a command
+// the arguments are solved, as the subject runs lem-in: go run . example00.txt
+func RunCommand(args []string) int {
+	if len(args) == 0 {
+		printUsage(os.Stdout)
+		return ExitUsage
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		if len(args) > 1 {
+			return RunCommand([]string{args[1], "--help"})
+		}
+		printUsage(os.Stdout)
+		return ExitOK
+	}
+	for _, command := range subcommands {
+		if command.name == args[0] {
+			return command.run(args[1:])
+		}
+	}
+	return RunSolve(args)
+}
+
+// printUsage lists the commands and the exit codes
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: go run . [command] [flags] <filename|->")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, command := range subcommands {
+		fmt.Fprintf(w, "  %-10v %v\n", command.name, command.summary)
+	}
+	fmt.Fprintln(w, "\nRun \"go run . help <command>\" for the flags of a command.")
+	fmt.Fprintln(w, "\nExit codes: 0 success, 1 invalid input, 2 wrong command line, 3 no path from ##start to ##end, 4 internal failure")
+}
+
+// newFlagSet returns the flags of a command, its --help prints the usage line, the description and the flags
+func newFlagSet(name, usage, description string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(os.Stdout)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: go run . %v %v\n\n%v\n", name, usage, description)
+		hasFlags := false
+		flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(flags.Output(), "\nFlags:")
+			flags.PrintDefaults()
+		}
+	}
+	return flags
+}
+
+// parseFlags parses the flags of a command, ok is false when the command has to stop with the returned exit code:
+// after --help, or on a wrong flag
+func parseFlags(flags *flag.FlagSet, args []string) (code int, ok bool) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK, false
+		}
+		return ExitUsage, false
+	}
+	return ExitOK, true
+}
+
+// ExitCode returns the exit code for an error of the parser or the solver
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, ErrNoPath):
+		return ExitNoPath
+	case errors.Is(err, ErrInvalid):
+		return ExitInvalid
+	}
+	return ExitInternal
+}
+
+// openInput opens a file, or stdin for -
+func openInput(filename string) (io.ReadCloser, error) {
+	if filename == "-" {
+		return io.NopCloser(os.Stdin), nil
+	}
+	return os.Open(filename)
+}
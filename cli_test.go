@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// run runs lem-in with the arguments and returns what it printed to stdout and stderr, and its exit code
+func run(t *testing.T, args ...string) (string, string, int) {
+	t.Helper()
+	dir := t.TempDir()
+	stdout, stderr := os.Stdout, os.Stderr
+	outFile, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errFile, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout, os.Stderr = outFile, errFile
+	code := RunCommand(args)
+	os.Stdout, os.Stderr = stdout, stderr
+	outFile.Close()
+	errFile.Close()
+
+	out, err := os.ReadFile(outFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	errOut, err := os.ReadFile(errFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), string(errOut), code
+}
+
+func TestExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	noPath := filepath.Join(dir, "nopath.txt")
+	if err := os.WriteFile(noPath, []byte("2\n##start\na 0 0\n##end\nb 1 1\nc 2 2\nd 3 3\na-c\nb-d"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	defer func() { os.Stdout = stdout }()
+
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"validate", "example00.txt"}, ExitOK},
+		{[]string{"validate", "badexample00.txt"}, ExitInvalid},
+		{[]string{"validate", noPath}, ExitNoPath},
+		{[]string{"validate", "missing.txt"}, ExitUsage},
+		{[]string{"validate"}, ExitUsage},
+		{[]string{"validate", "--help"}, ExitOK},
+		{[]string{"help", "solve"}, ExitOK},
+		{[]string{"--quiet", "--output", filepath.Join(dir, "out.txt"), "example00.txt"}, ExitOK},
+		{[]string{"solve", noPath}, ExitNoPath},
+		{[]string{"solve", "--no-such-flag", "example00.txt"}, ExitUsage},
+		{[]string{"lint", "badexample01.txt"}, ExitInvalid},
+	}
+	for _, test := range tests {
+		if code := RunCommand(test.args); code != test.code {
+			t.Errorf("%v: got exit code %d, want %d", test.args, code, test.code)
+		}
+	}
+
+	// --quiet leaves the colony out, so the file holds nothing but the moves
+	out, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/example00.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != string(want) {
+		t.Errorf("--quiet --output wrote\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	line := "1\n##start\ns 0 0\na 1 0\n##end\ne 2 0\ns-a\na-e"
+	for _, test := range []struct {
+		name   string
+		colony string
+		prune  bool // false to hand a colony without a path straight to the solver
+		code   int
+	}{
+		{"solved", line, true, ExitOK},
+		{"bad number of ants", "0\n##start\ns 0 0\n##end\ne 1 0\ns-e", true, ExitInvalid},
+		{"unknown blocked room", line + "\n##block 2 x", true, ExitInvalid},
+		{"bad ##block", line + "\n##block x", true, ExitInvalid},
+		{"no path", "1\n##start\ns 0 0\na 1 0\n##end\ne 2 0\nb 3 0\ns-a\ne-b", true, ExitNoPath},
+		{"no path found by the searches", "1\n##start\ns 0 0\na 1 0\n##end\ne 2 0\nb 3 0\ns-a\ne-b", false, ExitNoPath},
+		{"only room closed", strings.Replace(line, "1", "2", 1) + "\n##block 2 a", true, ExitNoPath},
+		{"ant cut off", line + "\n##block 2 a-e", true, ExitNoPath},
+		{"checkpoint out of the way", "1\n##start\ns 0 0\n##end\ne 1 0\n##checkpoint\nc 2 0\ns-e\ne-c", true, ExitNoPath},
+	} {
+		g, _, err := ParseGraph(strings.NewReader(test.colony))
+		if err == nil && test.prune {
+			_, _, err = PruneGraph(g)
+		}
+		if err == nil {
+			_, err = Solve(g)
+		}
+		if code := ExitCode(err); code != test.code {
+			t.Errorf("%v: got exit code %d for %v, want %d", test.name, code, err, test.code)
+		}
+	}
+	if code := ExitCode(errors.New("ERROR: invalid data format, but not from the parser")); code != ExitInternal {
+		t.Errorf("an untyped error got exit code %d", code)
+	}
+}
+
+func TestGenerateColony(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		lines := GenerateColony(5, 12, 4, seed)
+		if strings.Join(lines, "\n") != strings.Join(GenerateColony(5, 12, 4, seed), "\n") {
+			t.Fatalf("seed %d: the colony changes from one run to the next", seed)
+		}
+		g, _, err := ParseGraph(strings.NewReader(strings.Join(lines, "\n")))
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if len(g.Rooms) != 14 {
+			t.Errorf("seed %d: %d rooms, want 14", seed, len(g.Rooms))
+		}
+		// every room is on a path from the start room to the end room, so none is pruned
+		if rooms, _, err := PruneGraph(g); err != nil || rooms != 0 {
+			t.Errorf("seed %d: pruned %d rooms, %v", seed, rooms, err)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Severity tells whether a Finding makes the colony unusable or is only suspicious
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Finding is a problem found in a colony file
+type Finding struct {
+	Line     int      `json:"line"` // line number in the file, starting at 1, or 0 when the problem isn't tied to a line
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	if f.Line == 0 {
+		return fmt.Sprintf("%v: %v", f.Severity, f.Message)
+	}
+	return fmt.Sprintf("line %d: %v: %v", f.Line, f.Severity, f.Message)
+}
+
+// PrintFinding prints a finding as "file:line: severity: message"
+func PrintFinding(w io.Writer, filename string, f Finding) {
+	if f.Line == 0 {
+		fmt.Fprintf(w, "%v: %v: %v\n", filename, f.Severity, f.Message)
+		return
+	}
+	fmt.Fprintf(w, "%v:%d: %v: %v\n", filename, f.Line, f.Severity, f.Message)
+}
+
+// ColonyRoom is a room of a colony file together with the line it was defined on
+type ColonyRoom struct {
+	Name     string
+	X        int
+	Y        int
+	Line     int
+	Comments []string // comment lines right above the room
+	Commands []string // the ## commands that wait for this room, ##start for example
+}
+
+// ColonyLink is a link of a colony file together with the line it was defined on
+type ColonyLink struct {
+	From     string
+	To       string
+	Line     int
+	Comments []string // comment lines right above the link
+}
+
+// Colony is a colony file read without stopping at the first problem, so that every problem can be reported at once
+type Colony struct {
+	Ants        int
+	AntsLine    int
+	Start       string
+	End         string
+	Rooms       []ColonyRoom
+	Links       []ColonyLink
+	Blocks      []Block
+	Checkpoints []string
+	Header      []string // comment lines above the number of ants
+	Trailer     []string // comment lines below the last room or link
+}
+
+// ParseColony reads the lines of a colony file and collects every syntax problem it runs into on the way.
+// The ## commands go through the same registry and command mode as in the Parser, on a graph of their own
+func ParseColony(lines []string, options ParseOptions) (*Colony, []Finding) {
+	c := &Colony{}
+	var findings []Finding
+	report := func(line int, severity Severity, format string, args ...interface{}) {
+		findings = append(findings, Finding{line, severity, fmt.Sprintf(format, args...)})
+	}
+	registry := options.Commands
+	if registry == nil {
+		registry = DefaultCommands()
+	}
+	g := &Graph{Rooms: []*Room{}}
+
+	antsRead := false
+	var pending *CommandCall
+	var comments, commands []string
+	pendingLine := 0
+	for i, line := range lines {
+		number := i + 1
+		switch {
+		case line == "" && i == len(lines)-1:
+			report(number, SeverityError, "the file ends with an empty line")
+			continue
+		case line == "":
+			report(number, SeverityError, "blank line")
+			continue
+		}
+		if options.Whitespace == WhitespaceRuns {
+			line = options.Whitespace.Trim(line)
+		} else if strings.HasSuffix(line, "\r") || strings.TrimSpace(line) != line {
+			report(number, SeverityError, "leading or trailing whitespace")
+			line = strings.TrimSpace(line)
+		}
+		if antsRead && len(line) < 3 {
+			report(number, SeverityError, "line is too short (%v)", line)
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			if number == 1 {
+				report(number, SeverityError, "the first line must be the number of ants, not a comment or command")
+			}
+			if !strings.HasPrefix(line, "##") {
+				comments = append(comments, line)
+				continue
+			}
+			name, args := splitCommand(line)
+			handler, ok := registry[name]
+			if !ok {
+				if options.CommandMode == CommandsStrict {
+					report(number, SeverityError, "unknown command %v", name)
+				} else {
+					report(number, SeverityWarning, "unknown command %v is ignored", line)
+				}
+				comments = append(comments, line)
+				continue
+			}
+			blocks := len(g.Blocks)
+			call := &CommandCall{Graph: g, Name: name, Args: args, Text: line}
+			if err := handler(call); err != nil {
+				report(number, SeverityError, "%v", parserMessage(err, 0))
+				continue
+			}
+			switch {
+			case call.onRoom != nil:
+				// the command stays with its room, a command still waiting for a room is an error
+				if pending != nil {
+					report(pendingLine, SeverityError, "%v is not followed by a room", pending.Name)
+					commands = nil
+				}
+				pending, pendingLine = call, number
+				commands = append(commands, line)
+			case len(g.Blocks) == blocks:
+				// a command the colony has no field for stays in place, like a comment
+				comments = append(comments, line)
+			}
+			continue
+		}
+
+		if !antsRead {
+			antsRead = true
+			c.AntsLine = number
+			c.Header, comments = comments, nil
+			ants, err := strconv.Atoi(line)
+			switch {
+			case err != nil:
+				report(number, SeverityError, "the first line must be the number of ants")
+			case ants <= 0:
+				report(number, SeverityError, "the number of ants must be greater than 0")
+			default:
+				c.Ants = ants
+			}
+			continue
+		}
+
+		words := options.Whitespace.Fields(line)
+		switch {
+		case len(words) > 1:
+			room, ok := parseColonyRoom(line, words, number, report)
+			if !ok {
+				pending, commands = nil, nil
+				continue
+			}
+			if len(c.Links) > 0 {
+				report(number, SeverityError, "room %q is defined after the links", room.Name)
+			}
+			room.Comments, comments = comments, nil
+			room.Commands, commands = commands, nil
+			c.Rooms = append(c.Rooms, room)
+			if g.getRoom(room.Name) == nil {
+				g.AddRoom(room.Name)
+			}
+			if pending != nil {
+				if err := pending.onRoom(room.Name); err != nil {
+					report(number, SeverityError, "%v", parserMessage(err, 0))
+				}
+				pending = nil
+			}
+			continue
+		case strings.Contains(line, "-"):
+			names := strings.Split(words[0], "-")
+			if len(names) != 2 || names[0] == "" || names[1] == "" {
+				report(number, SeverityError, "a link must join exactly two rooms (%v)", line)
+				break
+			}
+			c.Links = append(c.Links, ColonyLink{From: names[0], To: names[1], Line: number, Comments: comments})
+			comments = nil
+		default:
+			report(number, SeverityError, "line is neither a room nor a link (%v)", line)
+		}
+		if pending != nil {
+			report(pendingLine, SeverityError, "%v is not followed by a room", pending.Name)
+			pending, commands = nil, nil
+		}
+	}
+
+	if pending != nil {
+		report(pendingLine, SeverityError, "%v is not followed by a room", pending.Name)
+	}
+	c.Trailer = comments
+	c.Start, c.End = g.StartRoomName, g.EndRoomName
+	c.Checkpoints, c.Blocks = g.Checkpoints, g.Blocks
+	if !antsRead {
+		report(0, SeverityError, "the file has no number of ants")
+	}
+	if c.Start == "" {
+		report(0, SeverityError, "no ##start room")
+	}
+	if c.End == "" {
+		report(0, SeverityError, "no ##end room")
+	}
+	return c, findings
+}
+
+// checkWithParser adds the problem the Parser that solve and validate use stops at, when the findings have no error yet.
+// The checks of lint, fmt and stats explain problems better and find them all at once, the parser has the last word
+// on the rest, so that a colony without errors is always one solve accepts
+func checkWithParser(lines []string, findings []Finding, options ParseOptions) []Finding {
+	if hasErrors(findings) {
+		return findings
+	}
+	if finding, ok := parserFinding(lines, options); ok {
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// hasErrors checks if any of the findings is an error
+func hasErrors(findings []Finding) bool {
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// parserFinding runs the lines through the Parser that solve and validate use, and turns the problem
+// it stops at into a finding, ok is false when the parser accepts the colony
+func parserFinding(lines []string, options ParseOptions) (Finding, bool) {
+	p := newParserWith(&Graph{Rooms: []*Room{}}, options)
+	for i, line := range lines {
+		if err := p.ParseLine(line); err != nil {
+			return Finding{i + 1, SeverityError, parserMessage(err, i+1)}, true
+		}
+	}
+	if err := p.Finish(); err != nil {
+		return Finding{0, SeverityError, parserMessage(err, 0)}, true
+	}
+	return Finding{}, false
+}
+
+// parserMessage turns a parser error into the wording of the other findings, without the ERROR prefix and line number
+func parserMessage(err error, line int) string {
+	message := strings.TrimPrefix(err.Error(), "ERROR: ")
+	message = strings.TrimPrefix(message, "invalid data format. ")
+	message = strings.TrimSuffix(message, fmt.Sprintf(" (line %d)", line))
+	if message == "" {
+		return message
+	}
+	return strings.ToLower(message[:1]) + message[1:]
+}
+
+// parseColonyRoom reads a "name x y" line, reporting what is wrong with it when it isn't a valid room
+func parseColonyRoom(line string, words []string, number int, report func(int, Severity, string, ...interface{})) (ColonyRoom, bool) {
+	if len(words) != 3 {
+		report(number, SeverityError, "a room must be \"name x y\" separated by single spaces (%v)", line)
+		return ColonyRoom{}, false
+	}
+	if err := ValidateRoomName(words[0]); err != nil {
+		report(number, SeverityError, "%v", err)
+		return ColonyRoom{}, false
+	}
+	x, errX := ParseCoordinate(words[1])
+	y, errY := ParseCoordinate(words[2])
+	for _, err := range []error{errX, errY} {
+		if err != nil {
+			report(number, SeverityError, "room %q: %v", words[0], err)
+			return ColonyRoom{}, false
+		}
+	}
+	return ColonyRoom{Name: words[0], X: x, Y: y, Line: number}, true
+}
+
+// Neighbours lists the rooms linked to every room, leaving out links to unknown rooms and links from a room to itself
+func (c *Colony) Neighbours() map[string][]string {
+	neighbours := map[string][]string{}
+	known := map[string]bool{}
+	for _, room := range c.Rooms {
+		known[room.Name] = true
+		neighbours[room.Name] = nil
+	}
+	for _, link := range c.Links {
+		if link.From == link.To || !known[link.From] || !known[link.To] || contains(neighbours[link.From], link.To) {
+			continue
+		}
+		neighbours[link.From] = append(neighbours[link.From], link.To)
+		neighbours[link.To] = append(neighbours[link.To], link.From)
+	}
+	return neighbours
+}
+
+// Reachable lists every room that can be reached from the given room
+func Reachable(neighbours map[string][]string, from string) map[string]bool {
+	seen := map[string]bool{from: true}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range neighbours[current] {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return seen
+}
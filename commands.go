@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CommandMode decides what happens to ## commands that aren't in the parser's registry
+type CommandMode int
+
+const (
+	// CommandsSpec ignores unknown commands like any other comment, as the subject asks
+	CommandsSpec CommandMode = iota
+	// CommandsStrict rejects unknown commands, so that a typo like ##strat can't go unnoticed
+	CommandsStrict
+)
+
+// ParseCommandMode reads a mode by its flag name, "spec" or "strict"
+func ParseCommandMode(s string) (CommandMode, error) {
+	switch s {
+	case "spec":
+		return CommandsSpec, nil
+	case "strict":
+		return CommandsStrict, nil
+	}
+	return CommandsSpec, fmt.Errorf("commands must be \"spec\" or \"strict\", not %q", s)
+}
+
+// CommandHandler runs one ## command of a colony. An error stops the parse at the command's line
+type CommandHandler func(call *CommandCall) error
+
+// Commands maps the name of a command, ##start for example, to its handler
+type Commands map[string]CommandHandler
+
+// CommandCall is one use of a ## command, it lets the handler act on the graph and on the room or link that follows
+type CommandCall struct {
+	Graph *Graph
+	Name  string   // the command, ##start for example
+	Args  []string // the words after the command on its line
+	Text  string   // the whole line
+
+	meta   map[string]string
+	onRoom func(name string) error
+}
+
+// Attach adds metadata to the room or link defined on the next line
+func (c *CommandCall) Attach(key, value string) {
+	if c.meta == nil {
+		c.meta = map[string]string{}
+	}
+	c.meta[key] = value
+}
+
+// OnRoom asks for the next line to be a room and runs f with its name once it has been added to the graph
+func (c *CommandCall) OnRoom(f func(name string) error) {
+	c.onRoom = f
+}
+
+// DefaultCommands returns a new registry with the commands lem-in knows: ##start, ##end, ##checkpoint and ##block.
+// Teams can add their own directives to it before parsing
+func DefaultCommands() Commands {
+	return Commands{
+		"##start": func(call *CommandCall) error {
+			if len(call.Args) > 0 {
+				return errors.New("##start takes no arguments")
+			}
+			if call.Graph.StartRoomName != "" {
+				return errors.New("More than one ##start or ##end")
+			}
+			call.OnRoom(func(name string) error {
+				call.Graph.StartRoomName = name
+				return nil
+			})
+			return nil
+		},
+		"##end": func(call *CommandCall) error {
+			if len(call.Args) > 0 {
+				return errors.New("##end takes no arguments")
+			}
+			if call.Graph.EndRoomName != "" {
+				return errors.New("More than one ##start or ##end")
+			}
+			call.OnRoom(func(name string) error {
+				call.Graph.EndRoomName = name
+				return nil
+			})
+			return nil
+		},
+		"##checkpoint": func(call *CommandCall) error {
+			if len(call.Args) > 0 {
+				return errors.New("##checkpoint takes no arguments")
+			}
+			call.OnRoom(func(name string) error {
+				call.Graph.Checkpoints = append(call.Graph.Checkpoints, name)
+				return nil
+			})
+			return nil
+		},
+		"##block": func(call *CommandCall) error {
+			block, err := ParseBlock(call.Text)
+			if err != nil {
+				return err
+			}
+			call.Graph.Blocks = append(call.Graph.Blocks, block)
+			return nil
+		},
+	}
+}
+
+// splitCommand splits a ## line into the command and its arguments
+func splitCommand(line string) (string, []string) {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return line, nil
+	}
+	return words[0], words[1:]
+}
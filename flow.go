@@ -0,0 +1,123 @@
+package main
+
+import "sort"
+
+// flowNetwork is a colony turned into a flow network where every room is split into an "in" and an "out" node
+// joined by an edge of capacity 1, so that every unit of flow from start to end is a path that shares no room with the others
+type flowNetwork struct {
+	capacity  map[[2]string]int
+	adjacency map[string][]string
+	source    string
+	sink      string
+}
+
+func roomIn(room string) string  { return room + " in" }
+func roomOut(room string) string { return room + " out" }
+
+// newFlowNetwork builds the flow network of the rooms and links in neighbours
+func newFlowNetwork(neighbours map[string][]string, start, end string) *flowNetwork {
+	n := &flowNetwork{
+		capacity:  map[[2]string]int{},
+		adjacency: map[string][]string{},
+		source:    roomOut(start),
+		sink:      roomIn(end),
+	}
+	// links get more capacity than any cut of rooms could have, so that a minimum cut only ever goes through rooms.
+	// A direct link from start to end can still only be used once per turn
+	linkCapacity := len(neighbours) + 1
+	for room, names := range neighbours {
+		n.addEdge(roomIn(room), roomOut(room), 1)
+		for _, name := range names {
+			if room == start && name == end {
+				n.addEdge(roomOut(room), roomIn(name), 1)
+			} else {
+				n.addEdge(roomOut(room), roomIn(name), linkCapacity)
+			}
+		}
+	}
+	return n
+}
+
+// addEdge adds an edge with the given capacity and the reverse edge the residual graph needs
+func (n *flowNetwork) addEdge(from, to string, capacity int) {
+	_, forward := n.capacity[[2]string{from, to}]
+	_, backward := n.capacity[[2]string{to, from}]
+	if !forward && !backward {
+		n.adjacency[from] = append(n.adjacency[from], to)
+		n.adjacency[to] = append(n.adjacency[to], from)
+		n.capacity[[2]string{to, from}] = 0
+	}
+	n.capacity[[2]string{from, to}] = capacity
+}
+
+// augment looks for one more path from source to sink in the residual graph and pushes a unit of flow along it
+func (n *flowNetwork) augment() bool {
+	previous := map[string]string{n.source: ""}
+	queue := []string{n.source}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range n.adjacency[current] {
+			if _, seen := previous[next]; seen || n.capacity[[2]string{current, next}] == 0 {
+				continue
+			}
+			previous[next] = current
+			queue = append(queue, next)
+		}
+	}
+	if _, found := previous[n.sink]; !found {
+		return false
+	}
+	for node := n.sink; node != n.source; node = previous[node] {
+		n.capacity[[2]string{previous[node], node}]--
+		n.capacity[[2]string{node, previous[node]}]++
+	}
+	return true
+}
+
+// maxFlow pushes flow until no path is left and returns how many paths were found
+func (n *flowNetwork) maxFlow() int {
+	flow := 0
+	for n.augment() {
+		flow++
+	}
+	return flow
+}
+
+// residualReachable lists every node that can still be reached from the source once the flow is maximal
+func (n *flowNetwork) residualReachable() map[string]bool {
+	seen := map[string]bool{n.source: true}
+	queue := []string{n.source}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range n.adjacency[current] {
+			if !seen[next] && n.capacity[[2]string{current, next}] > 0 {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return seen
+}
+
+// MaxDisjointPaths counts how many paths from start to end can be taken at the same time without sharing a room
+func MaxDisjointPaths(neighbours map[string][]string, start, end string) int {
+	return newFlowNetwork(neighbours, start, end).maxFlow()
+}
+
+// MinVertexCut finds the fewest rooms that cut every path from start to end, which are the rooms that limit how many
+// ants can move at once. A direct link between start and end can't be cut by any room and is left out
+func MinVertexCut(neighbours map[string][]string, start, end string) []string {
+	n := newFlowNetwork(neighbours, start, end)
+	n.maxFlow()
+	reachable := n.residualReachable()
+	cut := []string{}
+	for room := range neighbours {
+		if room != start && room != end && reachable[roomIn(room)] && !reachable[roomOut(room)] {
+			cut = append(cut, room)
+		}
+	}
+	sort.Strings(cut)
+	return cut
+}
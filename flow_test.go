@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMinVertexCut(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		colony   string
+		disjoint int
+		cut      string
+	}{
+		{"single corridor", "1\n##start\ns 0 0\na 1 0\nb 2 0\n##end\ne 3 0\ns-a\na-b\nb-e", 1, "a"},
+		{"funnel", "1\n##start\ns 0 0\na 1 0\nb 1 1\nc 2 0\n##end\ne 3 0\ns-a\ns-b\na-c\nb-c\nc-e", 1, "c"},
+		{"two rooms wide", "1\n##start\ns 0 0\na 1 0\nb 1 1\nc 2 0\nd 2 1\n##end\ne 3 0\ns-a\ns-b\na-c\nb-d\nc-e\nd-e\na-d", 2, "a b"},
+		{"direct link", "1\n##start\ns 0 0\na 1 0\n##end\ne 3 0\ns-a\na-e\ns-e", 2, "a"},
+		{"only the direct link", "1\n##start\ns 0 0\n##end\ne 3 0\ns-e", 1, ""},
+	} {
+		g, _, err := ParseGraph(strings.NewReader(test.colony))
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		neighbours := undirected(g)
+		if disjoint := MaxDisjointPaths(neighbours, g.StartRoomName, g.EndRoomName); disjoint != test.disjoint {
+			t.Errorf("%v: %d disjoint paths, want %d", test.name, disjoint, test.disjoint)
+		}
+		if cut := strings.Join(MinVertexCut(neighbours, g.StartRoomName, g.EndRoomName), " "); cut != test.cut {
+			t.Errorf("%v: cut %q, want %q", test.name, cut, test.cut)
+		}
+	}
+}
+
+func TestBottlenecksFlag(t *testing.T) {
+	if got := HighlightMoves("L1-c L2-a", map[string]bool{"c": true}); got != Highlight("L1-c")+" L2-a" {
+		t.Errorf("HighlightMoves gave %q", got)
+	}
+	stdout, stderr, code := run(t, "--bottlenecks", "example00.txt")
+	if code != ExitOK {
+		t.Fatalf("exit code %d: %v", code, stdout)
+	}
+	if !strings.Contains(stderr, "Bottleneck rooms: 2\n") {
+		t.Errorf("stderr is %q, want the bottleneck rooms", stderr)
+	}
+	if !strings.Contains(stdout, Highlight("2 2 5")) || !strings.Contains(stdout, Highlight("L1-2")) {
+		t.Errorf("the room and the moves into it aren't highlighted:\n%v", stdout)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FormatColony rewrites a colony in canonical form: the number of ants, the ##start room, the ##end room,
+// the other rooms sorted by name and the links with their endpoints in lexicographic order, sorted and without duplicates.
+// Duplicate links and links from a room to itself are dropped when fix is set and reported as errors otherwise
+func FormatColony(lines []string, fix bool, options ParseOptions) ([]string, []Finding) {
+	c, findings := ParseColony(lines, options)
+	// a map that can't be read back the same way can't be rewritten, the other lint problems are left to lint
+	names := map[string]bool{}
+	for _, room := range c.Rooms {
+		if names[room.Name] {
+			findings = append(findings, Finding{room.Line, SeverityError, fmt.Sprintf("duplicate room name %q", room.Name)})
+		}
+		names[room.Name] = true
+	}
+	for _, link := range c.Links {
+		if !names[link.From] || !names[link.To] {
+			findings = append(findings, Finding{link.Line, SeverityError, fmt.Sprintf("link %v-%v uses an unknown room", link.From, link.To)})
+		}
+	}
+
+	links := []ColonyLink{}
+	seen := map[string]bool{}
+	for _, link := range c.Links {
+		if link.From > link.To {
+			link.From, link.To = link.To, link.From
+		}
+		key := link.From + "-" + link.To
+		switch {
+		case link.From == link.To && !fix:
+			findings = append(findings, Finding{link.Line, SeverityError, fmt.Sprintf("room %q is linked to itself, use --fix to drop the link", link.From)})
+		case seen[key] && !fix:
+			findings = append(findings, Finding{link.Line, SeverityError, fmt.Sprintf("duplicate link %v, use --fix to drop it", key)})
+		case link.From == link.To || seen[key]:
+			findings = append(findings, Finding{link.Line, SeverityWarning, fmt.Sprintf("link %v dropped", key)})
+		default:
+			seen[key] = true
+			links = append(links, link)
+		}
+	}
+	// --fix drops links the parser rejects, so then only the formatted map is checked by the parser
+	if !fix {
+		findings = checkWithParser(lines, findings, options)
+	}
+	if hasErrors(findings) {
+		return nil, findings
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		if links[i].From != links[j].From {
+			return links[i].From < links[j].From
+		}
+		return links[i].To < links[j].To
+	})
+
+	var start, end *ColonyRoom
+	rooms := []ColonyRoom{}
+	for i := range c.Rooms {
+		switch c.Rooms[i].Name {
+		case c.Start:
+			start = &c.Rooms[i]
+		case c.End:
+			end = &c.Rooms[i]
+		default:
+			rooms = append(rooms, c.Rooms[i])
+		}
+	}
+	sort.SliceStable(rooms, func(i, j int) bool {
+		return rooms[i].Name < rooms[j].Name
+	})
+
+	formatted := append([]string{}, c.Header...)
+	formatted = append(formatted, strconv.Itoa(c.Ants))
+	for _, room := range append([]ColonyRoom{*start, *end}, rooms...) {
+		formatted = append(formatted, room.Comments...)
+		formatted = append(formatted, room.Commands...)
+		formatted = append(formatted, formatRoom(room))
+	}
+	for _, link := range links {
+		formatted = append(formatted, link.Comments...)
+		formatted = append(formatted, link.From+"-"+link.To)
+	}
+	for _, block := range c.Blocks {
+		formatted = append(formatted, block.String())
+	}
+	formatted = append(formatted, c.Trailer...)
+	if finding, ok := parserFinding(formatted, options); ok {
+		finding.Line, finding.Message = 0, "the formatted map is rejected, "+finding.Message
+		return nil, append(findings, finding)
+	}
+	return formatted, findings
+}
+
+// formatRoom turns a room back into its "name x y" line
+func formatRoom(room ColonyRoom) string {
+	return fmt.Sprintf("%v %d %d", room.Name, room.X, room.Y)
+}
+
+// RunFormat formats a colony file to stdout, or in place with -w, and returns the exit code
+func RunFormat(args []string) int {
+	flags := newFlagSet("fmt", "[flags] <filename>", "Rewrites a colony in the canonical layout: the ants, the ##start room, the ##end room, the other rooms sorted by name, then the links and blocks.")
+	fix := flags.Bool("fix", false, "drop duplicate links and links from a room to itself instead of failing")
+	write := flags.Bool("w", false, "write the result to the file instead of stdout")
+	parseOptions := addParseOptionFlags(flags)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitUsage
+	}
+	options, err := parseOptions.Options()
+	if err != nil {
+		fmt.Println(err)
+		return ExitUsage
+	}
+	filename := flags.Arg(0)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Println(err)
+		return ExitUsage
+	}
+
+	formatted, findings := FormatColony(strings.Split(string(data), "\n"), *fix, options)
+	for _, finding := range findings {
+		PrintFinding(os.Stderr, filename, finding)
+	}
+	if formatted == nil {
+		return ExitInvalid
+	}
+
+	output := strings.Join(formatted, "\n")
+	if !*write {
+		fmt.Print(output)
+		return ExitOK
+	}
+	if err := os.WriteFile(filename, []byte(output), 0o644); err != nil {
+		fmt.Println(err)
+		return ExitInternal
+	}
+	return ExitOK
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatColony(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		colony    string
+		fix       bool
+		formatted string // empty when the colony can't be formatted
+	}{
+		{
+			"canonical order",
+			"3\nc 2 2\n##end\ne 9 9\n#the entrance\n##start\ns 0 0\n##checkpoint\nb 1 1\nb-s\ne-c\nc-b",
+			false,
+			"3\n#the entrance\n##start\ns 0 0\n##end\ne 9 9\n##checkpoint\nb 1 1\nc 2 2\nb-c\nb-s\nc-e",
+		},
+		{
+			"comments and blocks",
+			"1\n##start\ns 0 0\n##block 2 s-e\n##end\ne 1 1\n#last\ns-e\n#trailer",
+			false,
+			"1\n##start\ns 0 0\n##end\ne 1 1\n#last\ne-s\n##block 2 s-e\n#trailer",
+		},
+		{"duplicate link", "1\n##start\ns 0 0\n##end\ne 1 1\ns-e\ne-s", false, ""},
+		{"duplicate link fixed", "1\n##start\ns 0 0\n##end\ne 1 1\ns-e\ne-s", true, "1\n##start\ns 0 0\n##end\ne 1 1\ne-s"},
+		{"unknown room", "1\n##start\ns 0 0\n##end\ne 1 1\ns-e\ns-x", true, ""},
+		{"not a colony solve accepts", "1\n##start\ns 0 0\n##end\ne 1 1\ns-e\n", false, ""},
+	} {
+		formatted, findings := FormatColony(strings.Split(test.colony, "\n"), test.fix, ParseOptions{})
+		if got := strings.Join(formatted, "\n"); got != test.formatted {
+			t.Errorf("%v: got\n%v\nwant\n%v\nfindings %v", test.name, got, test.formatted, findings)
+			continue
+		}
+		if formatted == nil {
+			continue
+		}
+		// formatting twice changes nothing and the result still parses
+		again, _ := FormatColony(formatted, false, ParseOptions{})
+		if strings.Join(again, "\n") != test.formatted {
+			t.Errorf("%v: formatting again gave\n%v", test.name, strings.Join(again, "\n"))
+		}
+		if _, _, err := ParseGraph(strings.NewReader(test.formatted)); err != nil {
+			t.Errorf("%v: %v", test.name, err)
+		}
+	}
+}
+
+func TestFormatKeepsCommands(t *testing.T) {
+	commands := DefaultCommands()
+	commands["##zone"] = func(call *CommandCall) error {
+		call.OnRoom(func(name string) error { return nil })
+		return nil
+	}
+	colony := "1\nz 5 5\n##end\ne 1 1\n##zone north\na 2 2\n##start\ns 0 0\n##tag\ns-a\na-e\ne-z"
+	want := "1\n##start\ns 0 0\n##end\ne 1 1\n##zone north\na 2 2\nz 5 5\na-e\n##tag\na-s\ne-z"
+	formatted, findings := FormatColony(strings.Split(colony, "\n"), false, ParseOptions{Commands: commands})
+	if got := strings.Join(formatted, "\n"); got != want {
+		t.Errorf("got\n%v\nwant\n%v\nfindings %v", got, want, findings)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+)
+
+// GenerateColony returns the lines of a random colony: the start and end rooms, rooms in between named r1, r2, ...,
+// the links that connect every room, and extraLinks more links between random rooms. Every room is on some path
+// from ##start to ##end. The same seed always gives the same colony
+func GenerateColony(ants, rooms, extraLinks int, seed int64) []string {
+	rng := rand.New(rand.NewSource(seed))
+	names := []string{"start"}
+	for i := 1; i <= rooms; i++ {
+		names = append(names, fmt.Sprintf("r%d", i))
+	}
+	names = append(names, "end")
+
+	// every room gets its own cell of a grid, so no two rooms share coordinates
+	width := int(math.Ceil(math.Sqrt(float64(len(names))))) * 2
+	cells := rng.Perm(width * width)
+	room := func(i int) string {
+		return fmt.Sprintf("%v %d %d", names[i], cells[i]%width, cells[i]/width)
+	}
+	lines := []string{fmt.Sprint(ants), "##start", room(0)}
+	for i := 1; i < len(names)-1; i++ {
+		lines = append(lines, room(i))
+	}
+	lines = append(lines, "##end", room(len(names)-1))
+
+	// a random tree from the start room reaches every room, the end room hangs off a leaf of it,
+	// so that every room is on a path from the start room to the end room
+	linked := map[string]bool{}
+	var links []string
+	link := func(a, b int) bool {
+		key := tunnelKey(names[a], names[b])
+		if a == b || linked[key] {
+			return false
+		}
+		linked[key] = true
+		links = append(links, names[a]+"-"+names[b])
+		return true
+	}
+	end := len(names) - 1
+	hasChild := make([]bool, end)
+	for i := 1; i < end; i++ {
+		parent := rng.Intn(i)
+		hasChild[parent] = true
+		link(parent, i)
+	}
+	for i := end - 1; i >= 0; i-- {
+		if !hasChild[i] {
+			link(i, end)
+		}
+	}
+	for added, tries := 0, 0; added < extraLinks && tries < extraLinks*100; tries++ {
+		if link(rng.Intn(len(names)), rng.Intn(len(names))) {
+			added++
+		}
+	}
+	return append(lines, links...)
+}
+
+// RunGenerate writes a random colony and returns the exit code
+func RunGenerate(args []string) int {
+	flags := newFlagSet("generate", "[flags]", "Writes a random colony in which every room is on a path from ##start to ##end.")
+	ants := flags.Int("ants", 10, "number of ants")
+	rooms := flags.Int("rooms", 20, "number of rooms besides the start and end rooms")
+	links := flags.Int("links", 10, "number of links added to the ones that connect every room")
+	seed := flags.Int64("seed", 0, "seed of the random colony, 0 picks one from the clock")
+	output := flags.String("output", "", "write the colony to this file instead of stdout")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() != 0 || *ants < 1 || *rooms < 0 || *links < 0 {
+		flags.Usage()
+		return ExitUsage
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	// like fmt, the colony has no newline after its last line, which the format doesn't allow
+	colony := strings.Join(GenerateColony(*ants, *rooms, *links, *seed), "\n")
+	if *output != "" {
+		if err := os.WriteFile(*output, []byte(colony), 0o644); err != nil {
+			fmt.Println(err)
+			return ExitUsage
+		}
+		return ExitOK
+	}
+	fmt.Print(colony)
+	return ExitOK
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// solveFile runs a colony file through the same steps as main and returns its graph and moves
+func solveFile(t *testing.T, filename string) (*Graph, []string, error) {
+	t.Helper()
+	file, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	solved, err := SolveColony(context.Background(), file, ParseOptions{}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	var moves bytes.Buffer
+	if err := solved.Solution.WriteMoves(&moves); err != nil {
+		t.Fatal(err)
+	}
+	return solved.Graph, strings.Split(strings.TrimSuffix(moves.String(), "\n"), "\n"), nil
+}
+
+func TestExamples(t *testing.T) {
+	// the most turns audit.md accepts for every example, 0 where it only sets a time limit
+	examples := []struct {
+		file     string
+		maxTurns int
+		maxTime  time.Duration
+	}{
+		{"example00.txt", 6, 0},
+		{"example01.txt", 8, 0},
+		{"example02.txt", 11, 0},
+		{"example03.txt", 6, 0},
+		{"example04.txt", 6, 0},
+		{"example05.txt", 8, 0},
+		{"example06.txt", 0, 90 * time.Second},
+		{"example07.txt", 0, 150 * time.Second},
+	}
+	for _, example := range examples {
+		t.Run(example.file, func(t *testing.T) {
+			started := time.Now()
+			_, moves, err := solveFile(t, example.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if example.maxTime > 0 && time.Since(started) > example.maxTime {
+				t.Errorf("took %v, the audit allows %v", time.Since(started), example.maxTime)
+			}
+			if example.maxTurns > 0 && len(moves) > example.maxTurns {
+				t.Errorf("%d turns, the audit allows %d", len(moves), example.maxTurns)
+			}
+
+			// the graph used for solving has been pruned, so the moves are replayed on a fresh one
+			file, err := os.Open(example.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			g, _, err := ParseGraph(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := Verify(g, moves); err != nil {
+				t.Error(err)
+			}
+
+			golden := filepath.Join("testdata", strings.TrimSuffix(example.file, ".txt")+".golden")
+			got := strings.Join(moves, "\n") + "\n"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("moves differ from %v, run go test -update if the change is intended\ngot:\n%vwant:\n%v", golden, got, string(want))
+			}
+		})
+	}
+}
+
+func TestBadExamples(t *testing.T) {
+	examples := []struct {
+		file string
+		kind string // part of the error message that tells what is wrong
+	}{
+		{"badexample00.txt", "Number of ants"},
+		{"badexample01.txt", "same room to same room"},
+		{"test.txt", "2 or more dashes"},
+	}
+	for _, example := range examples {
+		t.Run(example.file, func(t *testing.T) {
+			_, _, err := solveFile(t, example.file)
+			if err == nil {
+				t.Fatal("solved a colony that should have been rejected")
+			}
+			if !strings.HasPrefix(err.Error(), "ERROR: invalid data format") || !strings.Contains(err.Error(), example.kind) {
+				t.Errorf("got error %q, want an invalid data format error about %q", err, example.kind)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsIllegalMoves(t *testing.T) {
+	g, _, err := ParseGraph(strings.NewReader("2\n##start\ns 0 0\na 1 0\n##end\ne 2 0\ns-a\na-e"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedules := map[string][]string{
+		"two ants in one room": {"L1-a L2-a", "L1-e L2-e"},
+		"no tunnel":            {"L1-e L2-a", "L2-e"},
+		"ant left behind":      {"L1-a", "L1-e"},
+		"ant moves twice":      {"L1-a L1-e", "L2-a", "L2-e"},
+	}
+	for name, steps := range schedules {
+		if Verify(g, steps) == nil {
+			t.Errorf("%v: illegal moves were accepted", name)
+		}
+	}
+	if err := Verify(g, []string{"L1-a", "L1-e L2-a", "L2-e"}); err != nil {
+		t.Errorf("legal moves were rejected: %v", err)
+	}
+}
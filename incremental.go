@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GraphDelta is a change to a colony that has already been solved
+type GraphDelta struct {
+	AddRooms    []string
+	RemoveRooms []string
+	AddLinks    [][2]string
+	RemoveLinks [][2]string
+	Ants        int // the new number of ants, 0 when it doesn't change
+}
+
+// ApplyDelta returns a copy of the graph with the delta applied, rooms are removed first and links added last.
+// The graph itself is left untouched
+func ApplyDelta(g *Graph, delta GraphDelta) (*Graph, error) {
+	next := DeepCopyGraph(g)
+	if delta.Ants < 0 {
+		return nil, invalidError{"ERROR: invalid data format. Number of ants is negative or zero"}
+	}
+	if delta.Ants > 0 {
+		next.Ants = delta.Ants
+	}
+
+	for _, link := range delta.RemoveLinks {
+		from, to := next.getRoom(link[0]), next.getRoom(link[1])
+		if from == nil || to == nil || !(contains(from.Connections, to.Roomname) || contains(to.Connections, from.Roomname)) {
+			return nil, invalidError{fmt.Sprintf("ERROR: invalid data format. Link doesn't exist (%v-%v)", link[0], link[1])}
+		}
+		from.Connections = without(from.Connections, to.Roomname)
+		to.Connections = without(to.Connections, from.Roomname)
+	}
+	for _, name := range delta.RemoveRooms {
+		if name == next.StartRoomName || name == next.EndRoomName {
+			return nil, invalidError{"ERROR: invalid data format. The start and end rooms can't be removed"}
+		}
+		if next.getRoom(name) == nil {
+			return nil, invalidError{fmt.Sprintf("ERROR: invalid data format. Room doesn't exist (%v)", name)}
+		}
+		rooms := []*Room{}
+		for _, room := range next.Rooms {
+			if room.Roomname != name {
+				room.Connections = without(room.Connections, name)
+				rooms = append(rooms, room)
+			}
+		}
+		next.Rooms, next.index = rooms, nil
+		next.Checkpoints = without(next.Checkpoints, name)
+	}
+	for _, name := range delta.AddRooms {
+		if err := ValidateRoomName(name); err != nil {
+			return nil, invalidError{fmt.Sprintf("ERROR: invalid data format. Room name invalid, %v", err)}
+		}
+		if next.getRoom(name) != nil {
+			return nil, invalidError{fmt.Sprintf("ERROR: invalid data format. Duplicate room names are not allowed (%v)", name)}
+		}
+		next.AddRoom(name)
+	}
+	neighbours := undirected(next)
+	for _, link := range delta.AddLinks {
+		switch {
+		case link[0] == link[1]:
+			return nil, invalidError{"ERROR: invalid data format. You have a connection from the same room to same room"}
+		case next.getRoom(link[0]) == nil || next.getRoom(link[1]) == nil:
+			return nil, invalidError{fmt.Sprintf("ERROR: invalid data format. Room doesn't exist (%v-%v)", link[0], link[1])}
+		case contains(neighbours[link[0]], link[1]):
+			return nil, invalidError{fmt.Sprintf("ERROR: invalid data format. Duplicate Link (%v --- %v)", link[0], link[1])}
+		}
+		if err := next.AddLinks(link[0], link[1]); err != nil {
+			return nil, err
+		}
+		neighbours[link[0]] = append(neighbours[link[0]], link[1])
+		neighbours[link[1]] = append(neighbours[link[1]], link[0])
+	}
+	return next, nil
+}
+
+// without returns the names but one
+func without(names []string, name string) []string {
+	kept := []string{}
+	for _, other := range names {
+		if other != name {
+			kept = append(kept, other)
+		}
+	}
+	return kept
+}
+
+// Resolve applies a delta to a solved colony and updates its solution. The paths of the previous solution that still
+// exist become the flow of the new colony's flow network, and augmenting paths in its residual graph add paths or
+// replace the ones the delta broke, keeping the path set that needs the fewest turns. A full Solve only runs when the
+// previous paths can't be reused: with checkpoints or blocks, or when the paths that carried ants share rooms.
+// The new graph is returned unpruned, ready for the next delta
+func Resolve(g *Graph, previous *Solution, delta GraphDelta) (*Graph, *Solution, error) {
+	next, err := ApplyDelta(g, delta)
+	if err != nil {
+		return nil, nil, err
+	}
+	solving := DeepCopyGraph(next)
+	if _, _, err := PruneGraph(solving); err != nil {
+		return nil, nil, err
+	}
+	if solution, ok := augmentSolution(solving, previous); ok {
+		return next, solution, nil
+	}
+	solution, err := Solve(solving)
+	if err != nil {
+		return nil, nil, err
+	}
+	return next, solution, nil
+}
+
+// augmentSolution finds the paths of a graph starting from the paths of a previous solution, ok is false when they can't be used
+func augmentSolution(g *Graph, previous *Solution) (*Solution, bool) {
+	if previous == nil || previous.Replanned || len(g.Checkpoints) > 0 || len(g.Blocks) > 0 {
+		return nil, false
+	}
+	neighbours := undirected(g)
+	n := newFlowNetwork(neighbours, g.StartRoomName, g.EndRoomName)
+
+	// the paths that carried ants become flow, as long as the delta left them intact
+	used := map[string]bool{}
+	for i, ants := range AntsPerPath(previous.Ants, previous.Paths) {
+		if ants == 0 {
+			continue
+		}
+		rooms := strings.Split(previous.Paths[i], "-")
+		if !pathExists(neighbours, g.StartRoomName, rooms) {
+			continue
+		}
+		for _, room := range rooms[:len(rooms)-1] {
+			if used[room] {
+				return nil, false
+			}
+			used[room] = true
+		}
+		n.push(g.StartRoomName, rooms)
+	}
+
+	best := n.paths(g.StartRoomName, g.EndRoomName)
+	bestTurns := TurnCount(g.Ants, best)
+	for n.augment() {
+		paths := n.paths(g.StartRoomName, g.EndRoomName)
+		if turns := TurnCount(g.Ants, paths); len(best) == 0 || turns < bestTurns {
+			best, bestTurns = paths, turns
+		}
+	}
+	if len(best) == 0 {
+		return nil, false
+	}
+	return &Solution{Ants: g.Ants, Paths: best, Turns: bestTurns, Strategy: "incremental"}, true
+}
+
+// pathExists checks that every room of a path is still linked to the next one
+func pathExists(neighbours map[string][]string, start string, rooms []string) bool {
+	from := start
+	for _, room := range rooms {
+		if !contains(neighbours[from], room) {
+			return false
+		}
+		from = room
+	}
+	return true
+}
+
+// push sends a unit of flow along a path, given without its start room
+func (n *flowNetwork) push(start string, rooms []string) {
+	from := start
+	for i, room := range rooms {
+		n.addFlow(roomOut(from), roomIn(room))
+		if i < len(rooms)-1 {
+			n.addFlow(roomIn(room), roomOut(room))
+		}
+		from = room
+	}
+}
+
+// addFlow moves a unit of capacity of an edge to its reverse edge
+func (n *flowNetwork) addFlow(from, to string) {
+	n.capacity[[2]string{from, to}]--
+	n.capacity[[2]string{to, from}]++
+}
+
+// paths splits the flow into paths from start to end, without the start room, sorted like the solver sorts them.
+// A link carries flow when its reverse edge, which starts with no capacity, has some
+func (n *flowNetwork) paths(start, end string) []string {
+	taken := map[[2]string]bool{}
+	paths := []string{}
+	for _, first := range n.adjacency[roomOut(start)] {
+		if first == roomIn(start) || n.capacity[[2]string{first, roomOut(start)}] == 0 {
+			continue
+		}
+		rooms := []string{}
+		node := first
+		for {
+			room := strings.TrimSuffix(node, " in")
+			rooms = append(rooms, room)
+			if room == end {
+				break
+			}
+			next := ""
+			for _, candidate := range n.adjacency[roomOut(room)] {
+				edge := [2]string{roomOut(room), candidate}
+				if candidate != roomIn(room) && !taken[edge] && n.capacity[[2]string{candidate, roomOut(room)}] > 0 {
+					next, taken[edge] = candidate, true
+					break
+				}
+			}
+			node = next
+		}
+		paths = append(paths, strings.Join(rooms, "-"))
+	}
+	lenSorter(&paths)
+	return paths
+}
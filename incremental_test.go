@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	parse := func(colony string) *Graph {
+		g, _, err := ParseGraph(strings.NewReader(colony))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return g
+	}
+	solve := func(g *Graph) *Solution {
+		pruned := DeepCopyGraph(g)
+		if _, _, err := PruneGraph(pruned); err != nil {
+			t.Fatal(err)
+		}
+		solution, err := Solve(pruned)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return solution
+	}
+
+	g := parse("4\n##start\na 0 0\nb 1 1\nc 5 5\n##end\nd 2 2\na-b\nb-d\na-c\nc-d")
+	solution := solve(g)
+	for _, test := range []struct {
+		name  string
+		delta GraphDelta
+		paths string
+		turns int
+	}{
+		{"remove a room", GraphDelta{RemoveRooms: []string{"b"}}, "c-d", 5},
+		{"remove a link", GraphDelta{RemoveLinks: [][2]string{{"d", "c"}}}, "b-d", 5},
+		{"add a room", GraphDelta{AddRooms: []string{"e"}, AddLinks: [][2]string{{"a", "e"}, {"e", "d"}}, Ants: 6}, "b-d c-d e-d", 3},
+		{"change the ants", GraphDelta{Ants: 1}, "b-d", 2},
+		{"add a shortcut", GraphDelta{AddLinks: [][2]string{{"a", "d"}}}, "d b-d c-d", 2},
+	} {
+		next, resolved, err := Resolve(g, solution, test.delta)
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		used := []string{}
+		for i, ants := range AntsPerPath(resolved.Ants, resolved.Paths) {
+			if ants > 0 {
+				used = append(used, resolved.Paths[i])
+			}
+		}
+		if strings.Join(used, " ") != test.paths || resolved.Turns != test.turns || resolved.Strategy != "incremental" {
+			t.Errorf("%v: paths %v in %d turns by %v, want %v in %d turns", test.name, used, resolved.Turns, resolved.Strategy, test.paths, test.turns)
+		}
+		if err := Verify(next, resolved.Moves()); err != nil {
+			t.Errorf("%v: %v", test.name, err)
+		}
+	}
+	if len(g.Rooms) != 4 || g.Ants != 4 {
+		t.Errorf("the previous graph changed")
+	}
+
+	for _, delta := range []GraphDelta{
+		{RemoveRooms: []string{"a"}},
+		{RemoveRooms: []string{"x"}},
+		{RemoveLinks: [][2]string{{"b", "c"}}},
+		{AddRooms: []string{"b"}},
+		{AddRooms: []string{"#e"}},
+		{AddLinks: [][2]string{{"a", "b"}}},
+		{AddLinks: [][2]string{{"a", "x"}}},
+		{Ants: -1},
+	} {
+		if _, _, err := Resolve(g, solution, delta); err == nil || ExitCode(err) != ExitInvalid {
+			t.Errorf("%+v: got %v instead of an invalid data format error", delta, err)
+		}
+	}
+	if _, _, err := Resolve(g, solution, GraphDelta{RemoveRooms: []string{"b", "c"}}); ExitCode(err) != ExitNoPath {
+		t.Errorf("cutting every path: got %v instead of a no path error", err)
+	}
+
+	// with a checkpoint the paths can't be reused, the colony is solved again
+	checkpoint := parse("2\n##start\na 0 0\n##checkpoint\nb 1 1\nc 5 5\n##end\nd 2 2\na-b\nb-d\na-c\nc-d\nb-c")
+	next, resolved, err := Resolve(checkpoint, solve(checkpoint), GraphDelta{RemoveLinks: [][2]string{{"b", "d"}}})
+	if err != nil || resolved.Strategy == "incremental" {
+		t.Fatalf("checkpoint: %v by %v", err, resolved)
+	}
+	if err := Verify(next, resolved.Moves()); err != nil {
+		t.Errorf("checkpoint: %v", err)
+	}
+
+	// more ants on the examples keep every move legal and need no more turns than a full solve
+	for i := 0; i <= 5; i++ {
+		file := fmt.Sprintf("example%02d.txt", i)
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := parse(string(data))
+		next, resolved, err := Resolve(g, solve(g), GraphDelta{Ants: g.Ants * 3})
+		if err != nil {
+			t.Errorf("%v: %v", file, err)
+			continue
+		}
+		if err := Verify(next, resolved.Moves()); err != nil {
+			t.Errorf("%v: %v", file, err)
+		}
+		if full := solve(next); resolved.Turns > full.Turns {
+			t.Errorf("%v: %d turns, a full solve needs %d", file, resolved.Turns, full.Turns)
+		}
+	}
+}
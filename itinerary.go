@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Itinerary is the trip of one ant from the start room to the end room
+type Itinerary struct {
+	Ant       int
+	Path      int      // number of the path in the solution, starting at 1, or 0 when the ant was re-planned off its path
+	Departure int      // turn of the first move
+	Arrival   int      // turn of the move into the end room
+	Rooms     []string // every room the ant is in, from the start room to the end room
+}
+
+func (it Itinerary) String() string {
+	path := "-"
+	if it.Path > 0 {
+		path = strconv.Itoa(it.Path)
+	}
+	return fmt.Sprintf("L%d path %v departs turn %d arrives turn %d: %v", it.Ant, path, it.Departure, it.Arrival, strings.Join(it.Rooms, " "))
+}
+
+// Itineraries follows every ant through the moves of a solution, so that the schedule can be read per ant instead of per turn
+func Itineraries(solution *Solution, start string) []Itinerary {
+	itineraries := []Itinerary{}
+	byAnt := map[int]int{}
+	for turn, step := range solution.Moves() {
+		for _, move := range strings.Fields(step) {
+			parts := strings.SplitN(move[1:], "-", 2)
+			ant, _ := strconv.Atoi(parts[0])
+			i, ok := byAnt[ant]
+			if !ok {
+				i = len(itineraries)
+				byAnt[ant] = i
+				itineraries = append(itineraries, Itinerary{Ant: ant, Departure: turn + 1, Rooms: []string{start}})
+			}
+			itineraries[i].Arrival = turn + 1
+			itineraries[i].Rooms = append(itineraries[i].Rooms, parts[1])
+		}
+	}
+
+	sorted := make([]Itinerary, len(itineraries))
+	for i := range sorted {
+		it := itineraries[byAnt[i+1]]
+		route := strings.Join(it.Rooms[1:], "-")
+		for j, path := range solution.Paths {
+			if path == route {
+				it.Path = j + 1
+			}
+		}
+		sorted[i] = it
+	}
+	return sorted
+}
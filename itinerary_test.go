@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestItineraries(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		colony      string
+		itineraries []string
+	}{
+		{"one path", "2\n##start\ns 0 0\na 1 0\n##end\ne 2 0\ns-a\na-e", []string{
+			"L1 path 1 departs turn 1 arrives turn 2: s a e",
+			"L2 path 1 departs turn 2 arrives turn 3: s a e",
+		}},
+		{"two paths", "3\n##start\ns 0 0\na 1 0\nb 1 1\nc 2 1\n##end\ne 2 0\ns-a\na-e\ns-b\nb-c\nc-e", []string{
+			"L1 path 1 departs turn 1 arrives turn 2: s a e",
+			"L2 path 1 departs turn 2 arrives turn 3: s a e",
+			"L3 path 2 departs turn 1 arrives turn 3: s b c e",
+		}},
+		{"re-planned off its path", "4\n##start\ns 0 0\na 1 0\nb 1 1\nc 2 1\n##end\ne 2 0\ns-a\na-e\ns-b\nb-c\nc-e\na-c\n##block 2 a-e", []string{
+			"L1 path - departs turn 1 arrives turn 3: s a c e",
+			"L2 path - departs turn 2 arrives turn 4: s a c e",
+			"L3 path 2 departs turn 1 arrives turn 5: s b c e",
+			"L4 path - departs turn 3 arrives turn 6: s a c e",
+		}},
+	} {
+		g, _, err := ParseGraph(strings.NewReader(test.colony))
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		solution, err := Solve(g)
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		got := []string{}
+		for _, itinerary := range Itineraries(solution, g.StartRoomName) {
+			got = append(got, itinerary.String())
+		}
+		if strings.Join(got, "\n") != strings.Join(test.itineraries, "\n") {
+			t.Errorf("%v: got\n%v\nwant\n%v", test.name, strings.Join(got, "\n"), strings.Join(test.itineraries, "\n"))
+		}
+	}
+
+	stdout, _, code := run(t, "--by-ant", "--quiet", "example00.txt")
+	want := "L1 path 1 departs turn 1 arrives turn 3: 0 2 3 1\nL2 path 1 departs turn 2 arrives turn 4: 0 2 3 1\n" +
+		"L3 path 1 departs turn 3 arrives turn 5: 0 2 3 1\nL4 path 1 departs turn 4 arrives turn 6: 0 2 3 1\n"
+	if code != ExitOK || stdout != want {
+		t.Errorf("--by-ant printed\n%v\nwith exit code %d, want\n%v", stdout, code, want)
+	}
+}
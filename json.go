@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// JSONRoom is a room of the colony in the JSON output, with the metadata of its annotations
+type JSONRoom struct {
+	Name string            `json:"name"`
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+// JSONLink is a link of the colony in the JSON output, with the metadata of its annotations
+type JSONLink struct {
+	From string            `json:"from"`
+	To   string            `json:"to"`
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+// JSONReport is the colony and its solution as printed by --json, the solution is left out until it is added
+type JSONReport struct {
+	Ants     int        `json:"ants"`
+	Start    string     `json:"start"`
+	End      string     `json:"end"`
+	Rooms    []JSONRoom `json:"rooms"`
+	Links    []JSONLink `json:"links"`
+	Strategy string     `json:"strategy,omitempty"`
+	Paths    [][]string `json:"paths,omitempty"`
+	Turns    int        `json:"turns,omitempty"`
+	Moves    [][]string `json:"moves,omitempty"`
+}
+
+// NewJSONReport describes the colony of a graph, every room and link in the order they were read with its metadata
+func NewJSONReport(g *Graph) *JSONReport {
+	report := &JSONReport{Ants: g.Ants, Start: g.StartRoomName, End: g.EndRoomName, Rooms: []JSONRoom{}, Links: []JSONLink{}}
+	seen := map[string]bool{}
+	for _, room := range g.Rooms {
+		report.Rooms = append(report.Rooms, JSONRoom{Name: room.Roomname, Meta: room.Meta})
+		for _, next := range room.Connections {
+			key := tunnelKey(room.Roomname, next)
+			if next != "" && !seen[key] {
+				seen[key] = true
+				report.Links = append(report.Links, JSONLink{From: room.Roomname, To: next, Meta: g.LinkMeta[key]})
+			}
+		}
+	}
+	return report
+}
+
+// AddSolution adds the paths, with the start room, and the moves of every turn to the report
+func (r *JSONReport) AddSolution(solution *Solution, start string) {
+	r.Strategy = solution.Strategy
+	r.Turns = solution.Turns
+	r.Paths = [][]string{}
+	for _, path := range solution.Paths {
+		r.Paths = append(r.Paths, append([]string{start}, strings.Split(path, "-")...))
+	}
+	r.Moves = [][]string{}
+	for _, step := range solution.Moves() {
+		r.Moves = append(r.Moves, strings.Fields(step))
+	}
+}
+
+// Write prints the report as indented JSON
+func (r *JSONReport) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
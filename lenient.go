@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Warning is a deviation from the strict file format that lenient mode fixed instead of rejecting
+type Warning struct {
+	Line    int    // line number in the original file, starting at 1
+	Message string // what was wrong and what was done about it
+}
+
+func (w Warning) String() string {
+	return fmt.Sprintf("WARNING: line %d: %s", w.Line, w.Message)
+}
+
+// NormaliseLines turns a colony file with CRLF line endings, stray whitespace, blank lines and links between the rooms
+// into one that passes the strict validation, and returns a warning for every line it had to change
+func NormaliseLines(raw []string) ([]string, []Warning) {
+	var warnings []Warning
+	var lines []string
+	var lineNumbers []int
+	for i, line := range raw {
+		number := i + 1
+		if strings.HasSuffix(line, "\r") {
+			line = strings.TrimSuffix(line, "\r")
+			warnings = append(warnings, Warning{number, "CRLF line ending"})
+		}
+		if trimmed := strings.TrimSpace(line); trimmed != line {
+			line = trimmed
+			warnings = append(warnings, Warning{number, "leading or trailing whitespace removed"})
+		}
+		if line == "" {
+			// the newline at the very end of a file isn't a blank line
+			if i != len(raw)-1 {
+				warnings = append(warnings, Warning{number, "blank line removed"})
+			}
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			if collapsed := strings.Join(strings.Fields(line), " "); collapsed != line {
+				line = collapsed
+				warnings = append(warnings, Warning{number, "repeated whitespace collapsed"})
+			}
+		}
+		lines = append(lines, line)
+		lineNumbers = append(lineNumbers, number)
+	}
+
+	// every link has to come after the last room
+	lastRoom := -1
+	for i, line := range lines {
+		if IsRoom(line) {
+			lastRoom = i
+		}
+	}
+	var rooms, links []string
+	for i, line := range lines {
+		if i < lastRoom && i > 0 && isLink(line) {
+			links = append(links, line)
+			warnings = append(warnings, Warning{lineNumbers[i], "link between the rooms moved after the last room"})
+			continue
+		}
+		rooms = append(rooms, line)
+	}
+	sort.SliceStable(warnings, func(i, j int) bool {
+		return warnings[i].Line < warnings[j].Line
+	})
+	if len(links) == 0 {
+		return lines, warnings
+	}
+	// the moved links go right after the last room, in front of the links that were already there
+	head, tail := rooms[:lastRoom-len(links)+1], rooms[lastRoom-len(links)+1:]
+	normalised := append(append(append([]string{}, head...), links...), tail...)
+	return normalised, warnings
+}
+
+// isLink checks if a line is a "room1-room2" link
+func isLink(line string) bool {
+	return !strings.HasPrefix(line, "#") && !IsRoom(line) && strings.Contains(line, "-")
+}
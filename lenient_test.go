@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormaliseLines(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		raw      string
+		lines    string
+		warnings []string
+	}{
+		{"strict colony", "1\n##start\ns 0 0\n##end\ne 1 1\ns-e", "1\n##start\ns 0 0\n##end\ne 1 1\ns-e", nil},
+		{"newline at the end", "1\n##start\ns 0 0\n##end\ne 1 1\ns-e\n", "1\n##start\ns 0 0\n##end\ne 1 1\ns-e", nil},
+		{"CRLF", "1\r\n##start\r\ns 0 0\r\n##end\r\ne 1 1\r\ns-e", "1\n##start\ns 0 0\n##end\ne 1 1\ns-e", []string{
+			"line 1: CRLF line ending", "line 2: CRLF line ending", "line 3: CRLF line ending", "line 4: CRLF line ending", "line 5: CRLF line ending",
+		}},
+		{"whitespace", "1\n##start\n s 0 0\ns-e\n##end\ne\t1   1 \n", "1\n##start\ns 0 0\n##end\ne 1 1\ns-e", []string{
+			"line 3: leading or trailing whitespace removed", "line 4: link between the rooms moved after the last room",
+			"line 6: leading or trailing whitespace removed", "line 6: repeated whitespace collapsed",
+		}},
+		{"blank lines", "1\n\n##start\ns 0 0\n\n##end\ne 1 1\ns-e", "1\n##start\ns 0 0\n##end\ne 1 1\ns-e", []string{
+			"line 2: blank line removed", "line 5: blank line removed",
+		}},
+		{"comments keep their spaces", "1\n#a  comment\n##start\ns 0 0\n##end\ne 1 1\ns-e", "1\n#a  comment\n##start\ns 0 0\n##end\ne 1 1\ns-e", nil},
+	} {
+		lines, warnings := NormaliseLines(strings.Split(test.raw, "\n"))
+		if got := strings.Join(lines, "\n"); got != test.lines {
+			t.Errorf("%v: got\n%v\nwant\n%v", test.name, got, test.lines)
+		}
+		got := []string{}
+		for _, warning := range warnings {
+			got = append(got, strings.TrimPrefix(warning.String(), "WARNING: "))
+		}
+		if strings.Join(got, "\n") != strings.Join(test.warnings, "\n") {
+			t.Errorf("%v: got warnings %q, want %q", test.name, got, test.warnings)
+		}
+		if _, _, err := ParseGraph(strings.NewReader(strings.Join(lines, "\n"))); err != nil {
+			t.Errorf("%v: the normalised colony is rejected: %v", test.name, err)
+		}
+	}
+}
+
+func TestLenientFlag(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "legacy.txt")
+	if err := os.WriteFile(file, []byte("2\r\n##start\r\n  s 0 0\r\n\r\ns-a\r\na   1 1\r\n##end\r\ne 2 2\r\na-e\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, code := run(t, file); code != ExitInvalid {
+		t.Errorf("strict mode: exit code %d, want %d", code, ExitInvalid)
+	}
+	stdout, stderr, code := run(t, "--lenient", file)
+	if code != ExitOK {
+		t.Fatalf("lenient mode: exit code %d, %v", code, stdout)
+	}
+	want := "2\n##start\ns 0 0\na 1 1\n##end\ne 2 2\ns-a\na-e\n\nL1-a\nL1-e L2-a\nL2-e\n"
+	if stdout != want {
+		t.Errorf("lenient mode printed\n%v\nwant\n%v", stdout, want)
+	}
+	if warnings := strings.Count(stderr, "WARNING: "); warnings != 13 {
+		t.Errorf("%d warnings, want 13:\n%v", warnings, stderr)
+	}
+}
+
+func TestLenientFlagWithParseOptions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "zone.txt")
+	if err := os.WriteFile(file, []byte("1\r\n##start\r\ns 0 0\r\n##zone x\r\n##end\r\ne 1 1\r\ns-e\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		args   []string
+		code   int
+		output string // start of what is printed
+	}{
+		{[]string{"--lenient"}, ExitOK, "1\n##start\ns 0 0\n##zone x\n"},
+		{[]string{"--lenient", "--commands", "strict"}, ExitInvalid, "ERROR: invalid data format. Unknown command ##zone (line 4)"},
+	} {
+		stdout, _, code := run(t, append(test.args, file)...)
+		if code != test.code || !strings.HasPrefix(stdout, test.output) {
+			t.Errorf("%v: got exit code %d and\n%v\nwant %d and %q", test.args, code, stdout, test.code, test.output)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Lint runs every check on a colony, instead of stopping at the first problem like NoGo does
+func Lint(lines []string, options ParseOptions) []Finding {
+	c, findings := ParseColony(lines, options)
+	report := func(line int, severity Severity, format string, args ...interface{}) {
+		findings = append(findings, Finding{line, severity, fmt.Sprintf(format, args...)})
+	}
+
+	// duplicate names and coordinates
+	names := map[string]int{}
+	coords := map[[2]int]int{}
+	for _, room := range c.Rooms {
+		if first, ok := names[room.Name]; ok {
+			report(room.Line, SeverityError, "duplicate room name %q (first defined on line %d)", room.Name, first)
+		} else {
+			names[room.Name] = room.Line
+		}
+		if first, ok := coords[[2]int{room.X, room.Y}]; ok {
+			report(room.Line, SeverityError, "duplicate coordinates %d %d (first used on line %d)", room.X, room.Y, first)
+		} else {
+			coords[[2]int{room.X, room.Y}] = room.Line
+		}
+	}
+
+	// unknown rooms, self-links and duplicate links
+	links := map[string]int{}
+	for _, link := range c.Links {
+		for _, name := range []string{link.From, link.To} {
+			if _, ok := names[name]; !ok {
+				report(link.Line, SeverityError, "link %v-%v uses unknown room %q", link.From, link.To, name)
+			}
+		}
+		if link.From == link.To {
+			report(link.Line, SeverityError, "room %q is linked to itself", link.From)
+			continue
+		}
+		key := tunnelKey(link.From, link.To)
+		if first, ok := links[key]; ok {
+			report(link.Line, SeverityError, "duplicate link %v-%v (first defined on line %d)", link.From, link.To, first)
+		} else {
+			links[key] = link.Line
+		}
+	}
+
+	// orphan rooms, dead ends and rooms the ants can never reach
+	neighbours := c.Neighbours()
+	var fromStart map[string]bool
+	if c.Start != "" {
+		fromStart = Reachable(neighbours, c.Start)
+	}
+	for _, room := range c.Rooms {
+		if names[room.Name] != room.Line {
+			continue
+		}
+		switch degree := len(neighbours[room.Name]); {
+		case degree == 0:
+			report(room.Line, SeverityError, "room %q is not connected to the anthill", room.Name)
+		case fromStart != nil && !fromStart[room.Name]:
+			report(room.Line, SeverityWarning, "room %q can't be reached from ##start", room.Name)
+		case degree == 1 && room.Name != c.Start && room.Name != c.End:
+			report(room.Line, SeverityWarning, "room %q is a dead end", room.Name)
+		}
+	}
+	if c.Start != "" && c.End != "" && !fromStart[c.End] {
+		report(names[c.End], SeverityError, "##end room %q can't be reached from ##start room %q", c.End, c.Start)
+	}
+
+	findings = checkWithParser(lines, findings, options)
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+// RunLint lints a colony file, prints every finding and returns the exit code: 1 when there is at least one error
+func RunLint(args []string) int {
+	flags := newFlagSet("lint", "[flags] <filename>", "Runs every check on a colony and prints all the findings with their line numbers and severities.")
+	parseOptions := addParseOptionFlags(flags)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitUsage
+	}
+	options, err := parseOptions.Options()
+	if err != nil {
+		fmt.Println(err)
+		return ExitUsage
+	}
+	args = flags.Args()
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Println(err)
+		return ExitUsage
+	}
+	findings := Lint(strings.Split(string(data), "\n"), options)
+	errorCount := 0
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			errorCount++
+		}
+		PrintFinding(os.Stdout, args[0], finding)
+	}
+	fmt.Printf("%d errors, %d warnings\n", errorCount, len(findings)-errorCount)
+	if errorCount > 0 {
+		return ExitInvalid
+	}
+	return ExitOK
+}
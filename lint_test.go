@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	valid := "2\n##start\ns 0 0\na 1 0\nb 2 2\n##end\ne 2 0\ns-a\na-e\na-b"
+	for _, test := range []struct {
+		name     string
+		colony   string
+		findings []string // "line: severity: start of the message"
+	}{
+		{"valid", valid, []string{"5: warning: room \"b\" is a dead end"}},
+		{"comment above the ants", "#c\n" + valid, []string{"1: error: the first line must be the number of ants", "6: warning: room \"b\" is a dead end"}},
+		{"short comment", strings.Replace(valid, "##end", "#c\n##end", 1), []string{"5: warning: room \"b\" is a dead end", "6: error: line is too short"}},
+		{"empty last line", valid + "\n", []string{"5: warning: room \"b\" is a dead end", "11: error: the file ends with an empty line"}},
+		{"every problem at once", "2\n##start\ns 0 0\ns 1 1\n##end\ne 0 0\ns-x\ns-s\ns-e\ne-s", []string{
+			"4: error: duplicate room name \"s\"",
+			"6: error: duplicate coordinates 0 0",
+			"7: error: link s-x uses unknown room \"x\"",
+			"8: error: room \"s\" is linked to itself",
+			"10: error: duplicate link e-s",
+		}},
+		{"unreachable end", "1\n##start\ns 0 0\na 1 1\n##end\ne 2 2\nb 3 3\ns-a\ne-b", []string{
+			"4: warning: room \"a\" is a dead end",
+			"6: warning: room \"e\" can't be reached from ##start",
+			"6: error: ##end room \"e\" can't be reached",
+			"7: warning: room \"b\" can't be reached from ##start",
+		}},
+		{"parser rule", strings.Replace(valid, "a-b", "a-b\n##start x", 1), []string{"5: warning: room \"b\" is a dead end", "11: error: ##start takes no arguments"}},
+	} {
+		findings := Lint(strings.Split(test.colony, "\n"), ParseOptions{})
+		got := []string{}
+		for _, finding := range findings {
+			got = append(got, finding.String())
+		}
+		if len(got) != len(test.findings) {
+			t.Errorf("%v: got %q, want %q", test.name, got, test.findings)
+			continue
+		}
+		for i, want := range test.findings {
+			if !strings.HasPrefix(got[i], "line "+want) {
+				t.Errorf("%v: got %q, want %q", test.name, got[i], want)
+			}
+		}
+	}
+}
+
+func TestLintCommands(t *testing.T) {
+	valid := "1\n##start\ns 0 0\n##end\ne 1 0\ns-e"
+	zone := DefaultCommands()
+	zone["##zone"] = func(call *CommandCall) error {
+		if len(call.Args) != 1 {
+			return errors.New("##zone needs a name")
+		}
+		call.OnRoom(func(name string) error { return nil })
+		return nil
+	}
+	for _, test := range []struct {
+		name     string
+		colony   string
+		options  ParseOptions
+		findings []string
+	}{
+		{"unknown command", strings.Replace(valid, "s-e", "##zone north\ns-e", 1), ParseOptions{}, []string{"6: warning: unknown command ##zone north is ignored"}},
+		{"unknown command, strict", strings.Replace(valid, "s-e", "##zone north\ns-e", 1), ParseOptions{CommandMode: CommandsStrict}, []string{"6: error: unknown command ##zone"}},
+		{"registered command", strings.Replace(valid, "##end", "##zone north\n##end", 1), ParseOptions{Commands: zone, CommandMode: CommandsStrict}, []string{"4: error: ##zone is not followed by a room"}},
+		{"registered command without its room", strings.Replace(valid, "s-e", "##zone north\ns-e", 1), ParseOptions{Commands: zone}, []string{"6: error: ##zone is not followed by a room"}},
+		{"registered command failing", strings.Replace(valid, "##end", "##zone\n##end", 1), ParseOptions{Commands: zone}, []string{"4: error: ##zone needs a name"}},
+		{"second ##start", strings.Replace(valid, "##end", "##start\n##end", 1), ParseOptions{}, []string{"4: error: more than one ##start"}},
+		{"whitespace runs", strings.Replace(valid, "s-e", " m\t2  0 \ns-e\ne-m", 1), ParseOptions{Whitespace: WhitespaceRuns}, []string{"6: warning: room \"m\" is a dead end"}},
+		{"single spaces", strings.Replace(valid, "s-e", "m 2  0\ns-e\ne-m", 1), ParseOptions{}, []string{"6: error: a room must be", "8: error: link e-m uses unknown room"}},
+	} {
+		got := []string{}
+		for _, finding := range Lint(strings.Split(test.colony, "\n"), test.options) {
+			got = append(got, finding.String())
+		}
+		if len(got) != len(test.findings) {
+			t.Errorf("%v: got %q, want %q", test.name, got, test.findings)
+			continue
+		}
+		for i, want := range test.findings {
+			if !strings.HasPrefix(got[i], "line "+want) {
+				t.Errorf("%v: got %q, want %q", test.name, got[i], want)
+			}
+		}
+	}
+}
+
+// lint, fmt and stats have to agree with the parser solve uses: no lint error means the colony is accepted
+func TestLintAgreesWithParser(t *testing.T) {
+	files, err := filepath.Glob("*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	colonies := map[string]string{
+		"trailing newline": "1\n##start\na 0 0\n##end\nb 1 1\na-b\n",
+		"comment first":    "#c\n1\n##start\na 0 0\n##end\nb 1 1\na-b",
+		"short comment":    "1\n#c\n##start\na 0 0\n##end\nb 1 1\na-b",
+		"start argument":   "1\n##start now\na 0 0\n##end\nb 1 1\na-b",
+		"too many fields":  "1\n##start\na 0 0 0\n##end\nb 1 1\na-b",
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		colonies[file] = string(data)
+	}
+	for name, colony := range colonies {
+		_, _, parseErr := ParseGraph(strings.NewReader(colony))
+		lines := strings.Split(colony, "\n")
+		if lintErr := hasErrors(Lint(lines, ParseOptions{})); lintErr != (parseErr != nil) {
+			t.Errorf("%v: lint errors %v, parser error %v", name, lintErr, parseErr)
+		}
+		if formatted, _ := FormatColony(lines, false, ParseOptions{}); (formatted == nil) != (parseErr != nil) {
+			t.Errorf("%v: fmt formatted it %v, parser error %v", name, formatted != nil, parseErr)
+		}
+	}
+}
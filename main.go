@@ -2,13 +2,16 @@ package main
 
 import (
 	"bufio"
-	"errors"
+	"context"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // The Graph structure keeps track of all rooms the ant can take, the start and end rooms of the path and the number of ants
@@ -17,24 +20,13 @@ type Graph struct {
 	StartRoomName string
 	EndRoomName   string
 	Ants          int
-}
+	Checkpoints   []string // rooms every ant has to pass through on its way to the end room
+	Blocks        []Block  // rooms and tunnels that collapse during the run
 
-// Room represents a room in an ant hill
-type FRoom struct {
-	Name        string   // name of the room
-	X           int      // x-coordinate of the room
-	Y           int      // y-coordinate of the room
-	Connections []*FRoom // list of rooms connected to this room
-	Visited     bool     // whether this room has been visited or not
-	Distance    int      // distance of this room from the start room
-}
+	LinkMeta map[string]map[string]string // metadata attached to links by ## commands and #@ annotations, by tunnelKey
 
-// AntHill represents an ant hill with rooms, a start room, an end room, and the number of ants
-type AntHill struct {
-	FRooms    []*FRoom // list of all rooms in the ant hill
-	StartRoom *FRoom   // start room of the ant hill
-	EndRoom   *FRoom   // end room of the ant hill
-	Ants      int      // number of ants in the ant hill
+	index map[string]*Room // rooms by name, kept up to date by AddRoom and rebuilt by getRoom when Rooms changed
+	done  <-chan struct{}  // closed when the searches on the graph should give up, set by SolveContext
 }
 
 // The Room structure keeps track of the roomname, The rooms that the the current room is connected to and if the room has been visited before
@@ -42,24 +34,26 @@ type Room struct {
 	Roomname    string
 	Connections []string
 	Visited     bool
+	Meta        map[string]string // metadata attached to the room by ## commands and #@key=value annotations
 }
 
-var ah AntHill // global variable representing the ant hill
-
 // AddRoom is a method that adds a new room, name, to a graph
 func (g *Graph) AddRoom(name string) {
 	g.Rooms = append(g.Rooms, &Room{Roomname: name, Connections: []string{}, Visited: false})
+	if g.index != nil {
+		g.index[name] = g.Rooms[len(g.Rooms)-1]
+	}
 }
 
-// AddLinks is a method that adds a link from one room to another
-func (g *Graph) AddLinks(from, to string) {
+// AddLinks is a method that adds a link from one room to another, it fails when a room doesn't exist or the link is already there
+func (g *Graph) AddLinks(from, to string) error {
 	fromRoom := g.getRoom(from)
 	toRoom := g.getRoom(to)
 	if fromRoom == nil || toRoom == nil {
-		log.Fatalf("Room doesn't exist (%v-%v)", from, to)
+		return invalidError{fmt.Sprintf("ERROR: invalid data format. Room doesn't exist (%v-%v)", from, to)}
 	}
 	if contains(fromRoom.Connections, to) || contains(toRoom.Connections, from) {
-		log.Fatalf("ERROR: invalid data format. Duplicate Link (%v --- %v)", from, to)
+		return invalidError{fmt.Sprintf("ERROR: invalid data format. Duplicate Link (%v --- %v)", from, to)}
 	}
 	switch {
 	case fromRoom.Roomname == g.EndRoomName:
@@ -74,142 +68,379 @@ func (g *Graph) AddLinks(from, to string) {
 		fromRoom.Connections = append(fromRoom.Connections, toRoom.Roomname)
 		toRoom.Connections = append(toRoom.Connections, fromRoom.Roomname)
 	}
+	return nil
 }
 
-func (g *Graph) getRoom(name string) *Room {
-	for _, room := range g.Rooms {
-		if room.Roomname == name {
-			return room
-		}
+// RoomMeta returns the metadata attached to a room, nil when it has none or doesn't exist
+func (g *Graph) RoomMeta(name string) map[string]string {
+	if room := g.getRoom(name); room != nil {
+		return room.Meta
 	}
 	return nil
 }
 
-// ReadFile reads the given file and returns its contents as a list of lines
-func ReadFile(filename string) ([]string, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-	scanner := bufio.NewScanner(file)
-	var originalFileLines []string
-	for scanner.Scan() {
-		originalFileLines = append(originalFileLines, scanner.Text())
+// LinkMetaOf returns the metadata attached to the link between two rooms, in either direction
+func (g *Graph) LinkMetaOf(from, to string) map[string]string {
+	return g.LinkMeta[tunnelKey(from, to)]
+}
+
+// cancelled checks if the searches on the graph should give up
+func (g *Graph) cancelled() bool {
+	select {
+	case <-g.done:
+		return true
+	default:
+		return false
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
+}
+
+// getRoom looks a room up by name, so that graphs with many rooms can be built and searched quickly
+func (g *Graph) getRoom(name string) *Room {
+	if g.index == nil || len(g.index) != len(g.Rooms) {
+		g.index = make(map[string]*Room, len(g.Rooms))
+		for _, room := range g.Rooms {
+			g.index[room.Roomname] = room
+		}
 	}
-	return originalFileLines, nil
+	return g.index[name]
 }
 
 func main() {
 	log.SetFlags(0)
-
-	// ///////////////////////////////////////////////////
-	if len(os.Args) != 2 {
-		fmt.Println("Usage: go run . <filename>")
-		return
+	os.Exit(RunCommand(os.Args[1:]))
+}
+
+// solveAsBatch hands several maps over to batch together with the flags batch understands.
+// The flags that only make sense for a single map are refused rather than ignored
+func solveAsBatch(flags *flag.FlagSet) int {
+	args := []string{}
+	refused := []string{}
+	flags.Visit(func(f *flag.Flag) {
+		switch {
+		case f.Name == "json" || f.Name == "cache" || f.Name == "no-cache" || f.Name == "cache-dir" || f.Name == "whitespace" || f.Name == "commands":
+			args = append(args, "--"+f.Name+"="+f.Value.String())
+		case f.Name == "quiet" || f.Value.String() == f.DefValue:
+			// a batch never prints the colonies, and a flag left at its default asks for nothing
+		default:
+			refused = append(refused, "--"+f.Name)
+		}
+	})
+	if len(refused) > 0 {
+		fmt.Printf("%v can only be used with a single map\n", strings.Join(refused, ", "))
+		return ExitUsage
+	}
+	return RunBatch(append(append(args, "--"), flags.Args()...))
+}
+
+// RunSolve solves a colony and prints it followed by the moves of the ants, and returns the exit code
+func RunSolve(args []string) int {
+	flags := newFlagSet("solve", "[flags] <filename|->", "Solves a colony and prints it, an empty line and the moves of the ants, one line per turn.\nSeveral files, a directory or a glob pattern are solved as a batch.")
+	lenient := flags.Bool("lenient", false, "normalise whitespace, line endings, blank lines and misplaced links instead of rejecting them")
+	bottlenecks := flags.Bool("bottlenecks", false, "highlight the rooms that limit how many ants can move at once")
+	byAnt := flags.Bool("by-ant", false, "list every ant with its path, departure turn, arrival turn and rooms instead of the moves per turn")
+	summary := flags.String("summary", "", "print a summary of the schedule after the moves, to \"stderr\" or as \"comments\"")
+	order := flags.String("order", OrderByAnt, "order of the moves within a turn: \"ant\", \"path\" or \"room\"")
+	jsonOutput := flags.Bool("json", false, "print the colony, with its annotations, and the solution as JSON instead of the official output")
+	parseOptions := addParseOptionFlags(flags)
+	quiet := flags.Bool("quiet", false, "don't print the colony before the moves")
+	output := flags.String("output", "", "write the output to this file instead of stdout")
+	cacheOptions := addCacheFlags(flags)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	// several maps, a directory or a glob pattern are solved as a batch
+	if flags.NArg() > 1 || (flags.NArg() == 1 && isBatchPattern(flags.Arg(0))) {
+		return solveAsBatch(flags)
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitUsage
+	}
+	if *summary != "" && *summary != "stderr" && *summary != "comments" {
+		fmt.Println("--summary must be \"stderr\" or \"comments\"")
+		return ExitUsage
+	}
+	if *order != OrderByAnt && *order != OrderByPath && *order != OrderByRoom {
+		fmt.Println("--order must be \"ant\", \"path\" or \"room\"")
+		return ExitUsage
+	}
+	if *jsonOutput && (*byAnt || *summary == "comments") {
+		fmt.Println("--json can't be combined with --by-ant or --summary comments")
+		return ExitUsage
+	}
+	options, err := parseOptions.Options()
+	if err != nil {
+		fmt.Println(err)
+		return ExitUsage
 	}
-	originalFileLines, err := ReadFile(os.Args[1])
+	cache := cacheOptions.Cache()
+	input, err := openInput(flags.Arg(0))
 	if err != nil {
 		fmt.Println(err)
-		return
+		return ExitUsage
 	}
-
-	// Remove the comments from the original file lines
-	filteredLines := RemoveComments(originalFileLines)
-
-	// check length of slice to be minimum 6: 1st line is number of ants, 2nd  and 3rd line is start room, 4th and 5th line is end room, 6th line is a link
-	if len(filteredLines) < 6 {
-		NoGo("")
+	defer input.Close()
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Println(err)
+			return ExitUsage
+		}
+		defer file.Close()
+		out = file
 	}
 
-	// check if first line is a number
-	if !IsNumber(filteredLines[0]) {
-		NoGo("")
+	// the colony is parsed while it is read, in lenient mode it has to be normalised as a whole first
+	var gdfs *Graph
+	var originalFileLines []string
+	if *lenient {
+		var warnings []Warning
+		if originalFileLines, err = ReadLines(input); err == nil {
+			originalFileLines, warnings = NormaliseLines(originalFileLines)
+			for _, warning := range warnings {
+				fmt.Fprintln(os.Stderr, warning)
+			}
+			gdfs = &Graph{Rooms: []*Room{}}
+			err = PopulateGraph(originalFileLines, gdfs, options)
+		}
+	} else {
+		// --quiet never prints the colony, so its lines aren't kept
+		options.DiscardLines = *quiet
+		gdfs, originalFileLines, err = ParseGraphWith(input, options)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return ExitCode(err)
+	}
+	// the report describes the colony as it was read, before the rooms that can't be used are pruned
+	var report *JSONReport
+	if *jsonOutput {
+		report = NewJSONReport(gdfs)
+	}
+	prunedRooms, prunedLinks, err := PruneGraph(gdfs)
+	if err != nil {
+		fmt.Println(err)
+		return ExitCode(err)
+	}
+	if prunedRooms > 0 || prunedLinks > 0 {
+		fmt.Fprintf(os.Stderr, "Pruned %d rooms and %d links that can't be on any path from ##start to ##end\n", prunedRooms, prunedLinks)
+	}
+	cut := map[string]bool{}
+	if *bottlenecks {
+		rooms := MinVertexCut(undirected(gdfs), gdfs.StartRoomName, gdfs.EndRoomName)
+		for _, room := range rooms {
+			cut[room] = true
+		}
+		fmt.Fprintf(os.Stderr, "Bottleneck rooms: %v\n", strings.Join(rooms, " "))
+		for i, line := range originalFileLines {
+			if words := options.Whitespace.Fields(options.Whitespace.Trim(line)); len(words) == 3 && cut[words[0]] {
+				originalFileLines[i] = Highlight(line)
+			}
+		}
+	}
+	// Print the contents of the slice with a new line after each element
+	if report == nil && !*quiet {
+		fmt.Fprintln(out, strings.Join(originalFileLines, "\n")+"\n")
 	}
 
-	// convert first line to int and store in AntNum
-	ah.Ants, _ = strconv.Atoi(filteredLines[0])
-	filteredLines = filteredLines[1:]
-
-	// check if number of ants is valid
-	if ah.Ants <= 0 {
-		NoGo("Number of ants is invalid")
+	solveStart := time.Now()
+	solution, _, err := SolveCached(context.Background(), gdfs, cache)
+	if err != nil {
+		fmt.Println(err)
+		return ExitCode(err)
+	}
+	solveTime := time.Since(solveStart)
+	if *order != OrderByAnt {
+		solution.Steps = OrderMoves(solution, gdfs.StartRoomName, *order)
 	}
 
-	No2Dashes(filteredLines)
-	No3Spaces(filteredLines)
-	NoDuplicateLines(filteredLines)
-	NoHashInLastLine(filteredLines)
+	switch {
+	case report != nil:
+		report.AddSolution(solution, gdfs.StartRoomName)
+		err = report.Write(out)
+	case *byAnt:
+		for _, itinerary := range Itineraries(solution, gdfs.StartRoomName) {
+			fmt.Fprintln(out, itinerary)
+		}
+	case len(cut) > 0:
+		for _, step := range solution.Moves() {
+			fmt.Fprintln(out, HighlightMoves(step, cut))
+		}
+	default:
+		err = solution.WriteMoves(out)
+	}
+	if err != nil {
+		fmt.Println(err)
+		return ExitInternal
+	}
 
-	// extract start room
-	ExtractStartRoom(filteredLines)
-	filteredLines = DeleteStartRoom(filteredLines)
+	// the summary goes to stderr, or after the moves as comments that the format ignores
+	switch *summary {
+	case "stderr":
+		Summarize(solution, gdfs.StartRoomName, solveTime).Print(os.Stderr, "")
+	case "comments":
+		Summarize(solution, gdfs.StartRoomName, solveTime).Print(out, "# ")
+	}
+	return ExitOK
+}
 
-	// extract end room
-	ExtractEndRoom(filteredLines)
-	filteredLines = DeleteEndRoom(filteredLines)
+// Solution is the schedule found for a graph together with the paths the ants were sent along
+type Solution struct {
+	Ants      int
+	Paths     []string // paths in "room-room-end" format, without the start room
+	Steps     []string // the moves of every turn, one line per turn, built from the paths by Moves when they are needed
+	Turns     int
+	Strategy  string // the search that found the paths: "DFS", "BFS" or "checkpoint"
+	Replanned bool   // rooms or tunnels closed during the run, so the ants don't all keep to the paths
+	Spacing   int    // turns between two ants leaving on a checkpoint route that passes a room twice, 0 when they leave every turn
+}
 
-	// extract rooms
-	ExtractRooms(filteredLines)
-	OnlyConnections := DeleteAllRooms(filteredLines)
+// SolveContext is Solve that gives up once the context is done. The searches check the context on the way
+// and stop, so nothing keeps running after the context's error is returned
+func SolveContext(ctx context.Context, g *Graph) (*Solution, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	searched := *g
+	searched.done = ctx.Done()
+	solution, err := Solve(&searched)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return solution, err
+}
 
-	// check if any room is there in the connections that is not in the rooms
-	CheckRoomsInConnectionsPresent(OnlyConnections, GetAllRoomNames(&ah))
+// SolvedColony is a colony read, pruned and solved the same way by every command and endpoint
+type SolvedColony struct {
+	Graph    *Graph      // the graph without the rooms no path can use
+	Lines    []string    // the colony as it was read
+	Report   *JSONReport // the rooms and links as they were read, before pruning, without the solution yet
+	Solution *Solution   // nil when the colony was only read
+}
 
-	// Add Connections to the rooms where a connection is in the format "room1-room2" and room1 and room2 are in the rooms
-	AddConnections(OnlyConnections)
+// ReadColony parses a colony and prunes the rooms and links no path can use, which is everything short of solving it
+func ReadColony(r io.Reader, options ParseOptions) (*SolvedColony, error) {
+	g, lines, err := ParseGraphWith(r, options)
+	if err != nil {
+		return nil, err
+	}
+	report := NewJSONReport(g)
+	if _, _, err := PruneGraph(g); err != nil {
+		return nil, err
+	}
+	return &SolvedColony{Graph: g, Lines: lines, Report: report}, nil
+}
 
-	checkUnconnectedRooms(&ah)
+// SolveColony reads a colony with ReadColony and solves it, with the solution cache unless it is nil.
+// The solve gives up with the context's error once the context is done
+func SolveColony(ctx context.Context, r io.Reader, options ParseOptions, cache *Cache) (*SolvedColony, error) {
+	solved, err := ReadColony(r, options)
+	if err != nil {
+		return nil, err
+	}
+	if solved.Solution, _, err = SolveCached(ctx, solved.Graph, cache); err != nil {
+		return nil, err
+	}
+	return solved, nil
+}
 
-	/////////////////////////////////////////////////
+// WriteOutput writes the official output: the colony, an empty line and the moves of every turn
+func (s *SolvedColony) WriteOutput(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, strings.Join(s.Lines, "\n")+"\n"); err != nil {
+		return err
+	}
+	return s.Solution.WriteMoves(w)
+}
 
-	lines := validateFileGiveMeStrings()
-	_ = lines
-	//create a graph for the DFS search
-	gdfs := &Graph{Rooms: []*Room{}}
-	if err := PopulateGraph(lines, gdfs); err != nil {
-		fmt.Print(err)
-		return
+// Solve finds the paths for the ants of the graph and counts the turns they need, the graph itself is left untouched
+func Solve(g *Graph) (*Solution, error) {
+	// with checkpoints every path shares the checkpoint rooms, so all ants have to follow the single best path
+	if len(g.Checkpoints) > 0 {
+		path, err := CheckpointPath(g)
+		if err != nil {
+			return nil, err
+		}
+		paths := []string{path}
+		solution := &Solution{Ants: g.Ants, Paths: paths, Turns: TurnCount(g.Ants, paths), Strategy: "checkpoint"}
+		if spacing := RouteSpacing(path); spacing > 1 {
+			solution.Spacing, solution.Turns = spacing, (g.Ants-1)*spacing+len(strings.Split(path, "-"))
+		}
+		return solution, nil
 	}
-	gbfs := DeepCopyGraph(gdfs)
-	// Print the contents of the slice with a new line after each element
-	fmt.Println(strings.Join(originalFileLines, "\n") + "\n")
 
+	gdfs := DeepCopyGraph(g)
+	gbfs := DeepCopyGraph(g)
 	allPathsDFS, allPathsBFS := []string{}, []string{}
 	var path string
 	DFS(gdfs.StartRoomName, gdfs.EndRoomName, gdfs, path, &allPathsDFS)
 	BFS(gbfs.StartRoomName, gbfs.EndRoomName, gbfs, &allPathsBFS, ShortestPath)
 	lenSorter(&allPathsBFS)
 	lenSorter(&allPathsDFS)
-	antNum := gdfs.Ants
-	DFSSearch := AntSender(antNum, allPathsDFS)
-	BFSSearch := AntSender(antNum, allPathsBFS)
+	antNum := g.Ants
+	DFSTurns := TurnCount(antNum, allPathsDFS)
+	BFSTurns := TurnCount(antNum, allPathsBFS)
 
-	if len(DFSSearch) == 0 || len(BFSSearch) == 0 {
-		if len(DFSSearch) == 0 {
-			fmt.Println(fmt.Errorf("DFS Search Failed").Error())
+	if DFSTurns == 0 || BFSTurns == 0 {
+		failed := []string{}
+		if DFSTurns == 0 {
+			failed = append(failed, "DFS Search Failed")
 		}
-		if len(BFSSearch) == 0 {
-			fmt.Println(fmt.Errorf("BFS Search Failed").Error())
+		if BFSTurns == 0 {
+			failed = append(failed, "BFS Search Failed")
 		}
-		return
+		return nil, noPathError{strings.Join(failed, "\n")}
 	}
 
-	for _, step := range shorterSearch(DFSSearch, BFSSearch) {
-		fmt.Println(step)
+	solution := &Solution{Ants: antNum, Paths: allPathsDFS, Turns: DFSTurns, Strategy: "DFS"}
+	if DFSTurns > BFSTurns {
+		solution.Paths, solution.Turns, solution.Strategy = allPathsBFS, BFSTurns, "BFS"
+	}
+	if len(g.Blocks) > 0 {
+		steps, err := ScheduleWithBlocks(g, solution.Paths)
+		if err != nil {
+			return nil, err
+		}
+		solution.Steps, solution.Turns, solution.Replanned = steps, len(steps), true
 	}
+	return solution, nil
+}
+
+// Moves returns the moves of every turn, building them from the paths the first time they are needed
+func (s *Solution) Moves() []string {
+	if s.Steps == nil && s.Spacing > 1 {
+		s.Steps = SpacedMoves(s.Ants, s.Paths[0], s.Spacing)
+	}
+	if s.Steps == nil {
+		s.Steps = AntSender(s.Ants, s.Paths)
+	}
+	return s.Steps
+}
+
+// WriteMoves writes the moves of every turn, one line per turn. Moves that haven't been built yet are streamed
+// straight from the paths, so that huge numbers of ants never have to fit in memory
+func (s *Solution) WriteMoves(w io.Writer) error {
+	if s.Steps == nil && s.Spacing <= 1 {
+		return StreamMoves(w, s.Ants, s.Paths)
+	}
+	out := bufio.NewWriter(w)
+	for _, step := range s.Moves() {
+		if _, err := out.WriteString(step + "\n"); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
 }
 
 // BFS preforms a Breadth First Search of a graph from rooms start to end and puts all paths found in the []string paths
-func BFS(start, end string, g *Graph, paths *[]string, f func(graph *Graph, start string, end string, path []string) []string) {
+// f collects the paths it finds in found, which belongs to the call, so that any number of searches can run at once
+func BFS(start, end string, g *Graph, paths *[]string, f func(graph *Graph, start string, end string, path []string, found *[]string) []string) {
 	begin := g.getRoom(start)
 
-	for i := 0; i < len(begin.Connections); i++ {
+	for i := 0; i < len(begin.Connections) && !g.cancelled(); i++ {
 		var shortPath []string
-		ShortestPath(g, g.StartRoomName, g.EndRoomName, shortPath)
+		pathArray := []string{}
+		f(g, g.StartRoomName, g.EndRoomName, shortPath, &pathArray)
 		var shortStorer string
 		if len(pathArray) != 0 {
 			shortStorer = pathArray[0]
@@ -255,64 +486,26 @@ func BFS(start, end string, g *Graph, paths *[]string, f func(graph *Graph, star
 					*paths = append(*paths, pathStr)
 				}
 			}
-			pathArray = []string{}
 		}
 	}
 }
 
-// PopulateGraph sorts goes through the txt file with the ants and rooms and adds the rooms and links to the graph
-func PopulateGraph(lines []string, g *Graph) error {
-	var err error
-	// Parse the number of ants from the first line
-	g.Ants, err = strconv.Atoi(lines[0])
-	if err != nil {
-		return err
-	}
-	if g.Ants == 0 {
-		return errors.New("ERROR: invalid data format. Number of ants must be greater than 0")
-	}
-
-	// Iterate over the remaining lines to populate the graph
-	start := false
-	end := false
-	for _, line := range lines[1:] {
-		space := strings.Split(line, " ")
-
-		if len(space) > 1 {
-			if !isValidRoomName(line) {
-				log.Fatalf("ERROR: invalid data format. Room name or room coordinates invalid")
-			}
-			g.AddRoom(space[0])
-		}
-
-		if start {
-			g.StartRoomName = g.Rooms[len(g.Rooms)-1].Roomname
-			start = false
-		} else if end {
-			g.EndRoomName = g.Rooms[len(g.Rooms)-1].Roomname
-			end = false
-		}
-
-		hyphen := strings.Split(line, "-")
-		if len(hyphen) > 1 {
-			if hyphen[0] == hyphen[1] {
-				log.Fatalf("ERROR: invalid data format. You have a connection from the same room to same room.\n")
-			}
-			g.AddLinks(hyphen[0], hyphen[1])
-		}
-		switch line {
-		case "##start":
-			start = true
-		case "##end":
-			end = true
+// PopulateGraph goes through the lines of a colony and adds the rooms and links to the graph, read the way the options ask
+func PopulateGraph(lines []string, g *Graph, options ParseOptions) error {
+	p := newParserWith(g, options)
+	for _, line := range lines {
+		if err := p.ParseLine(line); err != nil {
+			return err
 		}
 	}
-
-	return nil
+	return p.Finish()
 }
 
 // DFS preforms a depth first search of a graph and returns the possible paths
 func DFS(current, end string, g *Graph, path string, pathList *[]string) {
+	if g.cancelled() {
+		return
+	}
 	curr := g.getRoom(current)
 	if current != end {
 		curr.Visited = true
@@ -349,20 +542,18 @@ func DFS(current, end string, g *Graph, path string, pathList *[]string) {
 	}
 }
 
-var pathArray []string
-
-// ShortestPath finds all the possible paths from start to end room using BFS and sorts them in ascending order
-func ShortestPath(graph *Graph, start string, end string, path []string) []string {
+// ShortestPath finds all the possible paths from start to end room using BFS and adds them to found
+func ShortestPath(graph *Graph, start string, end string, path []string, found *[]string) []string {
 	path = append(path, start)
-	if start == end {
+	if start == end || graph.cancelled() {
 		return path
 	}
 	shortest := make([]string, 0)
 	for _, node := range graph.getRoom(start).Connections {
 		if !contains(path, node) && !graph.isVisited(node) {
-			newPath := ShortestPath(graph, node, end, path)
+			newPath := ShortestPath(graph, node, end, path, found)
 			if len(newPath) > 0 && contains(newPath, graph.StartRoomName) && contains(newPath, end) {
-				pathArray = append(pathArray, fmt.Sprint(newPath))
+				*found = append(*found, fmt.Sprint(newPath))
 			}
 		}
 	}
@@ -373,9 +564,13 @@ func (graph *Graph) isVisited(str string) bool {
 	return graph.getRoom(str).Visited
 }
 
+// lenSorter sorts the paths from shortest to longest, and paths of the same length alphabetically so the order never changes between runs
 func lenSorter(paths *[]string) {
-	sort.Slice(*paths, func(i, j int) bool {
-		return len((*paths)[i]) < len((*paths)[j])
+	sort.SliceStable(*paths, func(i, j int) bool {
+		if len((*paths)[i]) != len((*paths)[j]) {
+			return len((*paths)[i]) < len((*paths)[j])
+		}
+		return (*paths)[i] < (*paths)[j]
 	})
 }
 
@@ -385,27 +580,18 @@ func AntSender(n int, pathList []string) []string {
 		pathLists[i] = strings.Split(path, "-")
 	}
 
-	queue := make([][]string, len(pathList))
-
-	for i := 1; i <= n; i++ {
-		minStepsIndex := 0
-		minSteps := len(pathLists[0]) + len(queue[0])
-		for j, path := range pathLists {
-			steps := len(path) + len(queue[j])
-			if steps < minSteps {
-				minSteps = steps
-				minStepsIndex = j
-			}
-		}
-		queue[minStepsIndex] = append(queue[minStepsIndex], strconv.Itoa(i))
+	ants := make([]int, n)
+	for i := range ants {
+		ants[i] = i + 1
 	}
+	queue := distributeAnts(ants, pathLists)
 
 	container := make([][][]string, len(queue))
 	for i, path := range queue {
 		for _, ant := range path {
 			adder := make([]string, len(pathLists[i]))
 			for j, room := range pathLists[i] {
-				adder[j] = "L" + ant + "-" + room
+				adder[j] = "L" + strconv.Itoa(ant) + "-" + room
 			}
 			container[i] = append(container[i], adder)
 		}
@@ -426,6 +612,24 @@ func AntSender(n int, pathList []string) []string {
 	return ProcessStrings(finalMoves)
 }
 
+// distributeAnts queues every ant on the path where it will arrive the soonest, given the ants already queued on each path
+func distributeAnts(ants []int, pathLists [][]string) [][]int {
+	queue := make([][]int, len(pathLists))
+	for _, ant := range ants {
+		minStepsIndex := 0
+		minSteps := len(pathLists[0]) + len(queue[0])
+		for j, path := range pathLists {
+			steps := len(path) + len(queue[j])
+			if steps < minSteps {
+				minSteps = steps
+				minStepsIndex = j
+			}
+		}
+		queue[minStepsIndex] = append(queue[minStepsIndex], ant)
+	}
+	return queue
+}
+
 func DeepCopyGraph(g *Graph) *Graph {
 	newGraph := &Graph{Rooms: []*Room{}}
 	for _, room := range g.Rooms {
@@ -433,12 +637,17 @@ func DeepCopyGraph(g *Graph) *Graph {
 			Roomname:    room.Roomname,
 			Connections: make([]string, len(room.Connections)),
 			Visited:     room.Visited,
+			Meta:        room.Meta,
 		})
 		copy(newGraph.Rooms[len(newGraph.Rooms)-1].Connections, room.Connections)
 	}
 	newGraph.StartRoomName = g.StartRoomName
 	newGraph.EndRoomName = g.EndRoomName
 	newGraph.Ants = g.Ants
+	newGraph.Checkpoints = append([]string{}, g.Checkpoints...)
+	newGraph.Blocks = append([]Block{}, g.Blocks...)
+	newGraph.LinkMeta = g.LinkMeta
+	newGraph.done = g.done
 	return newGraph
 }
 
@@ -470,388 +679,32 @@ func contains(s []string, name string) bool {
 	return false
 }
 
-func shorterSearch(DFSSearch, BFSSearch []string) []string {
-	if len(DFSSearch) > len(BFSSearch) {
-		return BFSSearch
-	}
-	return DFSSearch
-}
-
-func validateFileGiveMeStrings() []string {
-	// check if the file exists
-	f, err := os.Stat(os.Args[1])
-	if err != nil {
-		if os.IsNotExist(err) {
-			log.Fatalf("ERROR: invalid data format. File does not exist")
-		}
-	}
-	// store the whole file in a string
-	data, err := os.ReadFile(f.Name())
-	if err != nil {
-		log.Fatal("ERROR: invalid data format. File reading error", err)
-	}
-	// split the string into lines
-	lines := strings.Split(string(data), "\n")
-	// min lines need to be
-	// 1. numofants
-	// 2. startroom
-	// 3. startroomname
-	// 4. endroom
-	// 5. endroomname
-	// 6. onceconnection
-
-	if len(lines) < 6 {
-		log.Fatal("ERROR: invalid data format. Not enough lines")
-	}
-
-	// check if all the lines apart from the first one have a min length of 3
-	for i := 1; i < len(lines); i++ {
-		if len(lines[i]) < 3 {
-			log.Fatal("ERROR: invalid data format. Line is too short")
-		}
-	}
-
-	// check if the first line is a number
-	numA, err := strconv.Atoi(lines[0])
-	if err != nil {
-		log.Fatal("ERROR: invalid data format. First line is not a number")
-	}
-	if numA <= 0 {
-		log.Fatal("ERROR: invalid data format. Number of ants is negative or zero")
-	}
-	// check if there are more than two line with ##start or ##end
-	startCount := 0
-	endCount := 0
-	for _, s := range lines {
-		if strings.Contains(s, "##start") {
-			startCount++
-		}
-		if strings.Contains(s, "##end") {
-			endCount++
-		}
-	}
-	if startCount > 1 || endCount > 1 {
-		log.Fatal("ERROR: invalid data format. More than one ##start or ##end")
-	}
-	if startCount == 0 || endCount == 0 {
-		log.Fatal("ERROR: invalid data format. No ##start or ##end")
-	}
-	// if there is any line which starts from #, and it's length is greater than 1 and it doesn't equal ##start or ##end, then remove that line from lines
-	for i, s := range lines {
-		if strings.HasPrefix(s, "#") && len(s) > 1 && !strings.Contains(s, "##start") && !strings.Contains(s, "##end") {
-			lines = append(lines[:i], lines[i+1:]...)
-		}
-	}
-
-	// check if there is atleast one line with ##start and one line with ##end
-	for i, s := range lines {
-		if strings.Contains(s, "##start") && i+1 < len(lines) {
-			if !isValidRoomName(lines[i+1]) {
-				log.Fatal("ERROR: invalid data format. Invalid room name")
-			}
-			break
-		}
-		if strings.Contains(s, "##end") && i+1 < len(lines) {
-			if !isValidRoomName(lines[i+1]) {
-				log.Fatal("ERROR: invalid data format. Invalid room name")
-			}
-			break
-		}
-	}
-	chkConsRInTheEnd(lines)
-	chkDuplicateCoords(lines)
-	return lines
-}
-
-// check for connections in the end
-func chkConsRInTheEnd(lines []string) {
-	// check if the last line has a "-"
-	for {
-		if strings.Contains((lines[len(lines)-1]), "-") {
-			lines = lines[:len(lines)-1]
-		} else {
-			break
-		}
-	}
-	// now that we eliminated all the conitinous "-" contianing strings fromt he end, we are golden. Just now check if there is any other fucking line with "-"
-	for _, s := range lines {
-		if strings.Contains(s, "-") {
-			log.Fatal("ERROR: invalid data format. Invalid connection, all connections have to be continuous in the end")
-		}
-	}
-}
-
-func chkDuplicateCoords(lines []string) {
-	// check if there are duplicate coordinates
-	coords := make(map[string]bool)
-	countCoord := 0
-	// get all the lines with coordinates
-	for _, s := range lines {
-		if strings.Contains(s, " ") {
-			countCoord++
-			words := strings.Fields(s)
-			coords[words[1]+" "+words[2]] = true
-		}
-	}
-	// check if the length of the map is equal to the number of lines with coordinates
-	if len(coords) != countCoord {
-		log.Fatal("ERROR: invalid data format. Duplicate coordinates")
-	}
-}
-
-func isValidRoomName(name string) bool {
-	// room is the the format name x y
-	words := strings.Fields(name)
-	if len(words) != 3 {
-		return false
-	}
-	// check if the second and third word can be converted to int
-	_, err := strconv.Atoi(words[1])
-	if err != nil {
-		return false
-	}
-	_, err = strconv.Atoi(words[2])
-	return err == nil
-}
-
-//////////////////////////////////////////////////////////////////////////
-
-//-FROOM
-
-// checkUnconnectedRooms checks if there are rooms that are not connected to the anthill
-func checkUnconnectedRooms(ah *AntHill) {
-	for _, room := range ah.FRooms {
-		if len(room.Connections) == 0 {
-			NoGo(fmt.Sprintf("The room \"%v\" is not connected to the anthill", room.Name))
-		}
-	}
-}
-
-// AddConnections adds connections between rooms based on the given list of connections in incoming format ["room1-room2", "room2-room3", ...]
-func AddConnections(OnlyConnections []string) {
-	for _, connection := range OnlyConnections {
-		room1Name := strings.Split(connection, "-")[0]
-		room2Name := strings.Split(connection, "-")[1]
-		room1 := GetRoomByName(room1Name)
-		room2 := GetRoomByName(room2Name)
-		room1.Connections = append(room1.Connections, room2)
-		room2.Connections = append(room2.Connections, room1)
-	}
-}
-
-func GetRoomByName(name string) *FRoom {
-	for _, room := range ah.FRooms {
-		if room.Name == name {
-			return room
-		}
-	}
-	return nil
-}
-
-// RemoveComments removes the comments from the original file lines
-func RemoveComments(originalFileLines []string) []string {
-	var filteredLines []string
-	for _, line := range originalFileLines {
-		if strings.HasPrefix(line, "#") && line != "##end" && line != "##start" {
-			continue
-		}
-		filteredLines = append(filteredLines, line)
-	}
-	return filteredLines
-}
-
 // IsNumber checks if a string is a number
 func IsNumber(s string) bool {
 	_, err := strconv.Atoi(s)
 	return err == nil
 }
 
-// No2Dashes checks if there are 2 or more dashes in a line
-func No2Dashes(s []string) {
-	for _, line := range s {
-		if len(strings.Split(line, "-")) > 2 {
-			NoGo("2 or more dashes in a line are not allowed")
-		}
-	}
-}
-
-// No3Spaces checks if there are 3 or more spaces in a line
-func No3Spaces(s []string) {
-	for _, line := range s {
-		if len(strings.Split(line, " ")) > 3 {
-			NoGo("3 or more spaces in a line are not allowed")
-		}
-	}
-}
-
-// ExtractStartRoom extracts the start room from the slice
-func ExtractStartRoom(s []string) {
-	for i, line := range s {
-		if line == "##start" {
-			if i+1 < len(s) && IsRoom(s[i+1]) {
-				ah.StartRoom = ConvertToRoom(s[i+1])
-			} else {
-				NoGo("")
-			}
-		}
-	}
-}
-
-// ExtractEndRoom extracts the end room from the slice
-func ExtractEndRoom(s []string) {
-	for i, line := range s {
-		if line == "##end" {
-			if i+1 < len(s) && IsRoom(s[i+1]) {
-				ah.EndRoom = ConvertToRoom(s[i+1])
-			} else {
-				NoGo("")
-			}
-		}
-	}
-}
-
-// DeleteStartRoom deletes the start room from the slice
-func DeleteStartRoom(s []string) []string {
-	var filteredLines []string
-	startRoomIndex := -1
-	for i, line := range s {
-		if i == startRoomIndex {
-			continue
-		}
-		if line == "##start" {
-			startRoomIndex = i + 1
-			continue
-		}
-		filteredLines = append(filteredLines, line)
-	}
-	return filteredLines
-}
-
-// DeleteEndRoom deletes the end room from the slice
-func DeleteEndRoom(s []string) []string {
-	var filteredLines []string
-	endRoomIndex := -1
-	for i, line := range s {
-		if i == endRoomIndex {
-			continue
-		}
-		if line == "##end" {
-			endRoomIndex = i + 1
-			continue
-		}
-		filteredLines = append(filteredLines, line)
-	}
-	return filteredLines
-}
-
-// DeleteAllRooms deletes all the rooms from the slice
-func DeleteAllRooms(s []string) []string {
-	var filteredLines []string
-	for _, line := range s {
-		if !IsRoom(line) {
-			filteredLines = append(filteredLines, line)
-		}
-	}
-	return filteredLines
-}
-
-// NoDuplicateLines checks if there are duplicate lines in the slice
-func NoDuplicateLines(s []string) {
-	for i, line := range s {
-		for j, line2 := range s {
-			if i != j && line == line2 {
-				NoGo("Duplicate lines are not allowed")
-			}
-		}
-	}
-}
-
-// NoDuplicateCoordsOrNames checks if there are duplicate coordinates in the slice
-func NoDuplicateCoordsOrNames(s []*FRoom) {
-	for i, room := range s {
-		for j, room2 := range s {
-			if i != j && room.X == room2.X && room.Y == room2.Y {
-				NoGo("Duplicate coordinates are not allowed")
-			}
-			if i != j && room.Name == room2.Name {
-				NoGo("Duplicate room names are not allowed")
-			}
-		}
-	}
-}
-
-// ExtractRooms extracts all the rooms from the slice
-func ExtractRooms(s []string) {
-	var rooms []*FRoom
-	for _, line := range s {
-		if IsRoom(line) {
-			rooms = append(rooms, ConvertToRoom(line))
-		}
-	}
-	rooms = append(rooms, ah.StartRoom)
-	rooms = append(rooms, ah.EndRoom)
-	NoDuplicateCoordsOrNames(rooms)
-	// fill AntHill with the rooms
-	ah.FRooms = rooms
-}
-
-// ConvertToRoom converts a string to a room
-func ConvertToRoom(roomStr string) *FRoom {
-	// split the room line into a slice
-	roomStrSlice := strings.Split(roomStr, " ")
-	// convert the coordinates to ints
-	rName := roomStrSlice[0]
-	x, _ := strconv.Atoi(roomStrSlice[1])
-	y, _ := strconv.Atoi(roomStrSlice[2])
-	return &FRoom{
-		Name: rName,
-		X:    x,
-		Y:    y,
-	}
-}
-
-// No # in last line, or it is a start or end room
-func NoHashInLastLine(s []string) {
-	if strings.HasPrefix(s[len(s)-1], "#") {
-		NoGo("")
-	}
-}
-
 // IsRoom checks if a string is a room
 func IsRoom(s string) bool {
 	return !((len(strings.Split(s, " ")) != 3) || !IsNumber(strings.Split(s, " ")[1]) || !IsNumber(strings.Split(s, " ")[2]))
 }
 
-func NoGo(msg string) {
-	fmt.Println("ERROR: invalid data format")
-	if msg != "" {
-		fmt.Println("\033[101m" + msg + "\033[0m")
-	}
-	os.Exit(1)
+// Highlight gives a string a red background in the terminal
+func Highlight(s string) string {
+	return "\033[101m" + s + "\033[0m"
 }
 
-func CheckRoomsInConnectionsPresent(OnlyConnections []string, AllRooms []string) {
-	for _, connectionStr := range OnlyConnections {
-		// split the connectionStr line into a slice of roomsnames by "-"
-		roomNames := strings.Split(connectionStr, "-")
-		if !Contains(AllRooms, roomNames[0]) || !Contains(AllRooms, roomNames[1]) {
-			NoGo("ERROR: room in connection not present in rooms")
-		}
+// HighlightMoves highlights every move of a turn that goes into one of the given rooms
+func HighlightMoves(step string, rooms map[string]bool) string {
+	if len(rooms) == 0 {
+		return step
 	}
-}
-
-// Contains checks if a string is in a slice
-func Contains(slice []string, elem string) bool {
-	return strings.Contains(strings.Join(slice, "😎"), elem)
-}
-
-// CheckRoomsInConnectionsPresent checks if all the rooms in the connections are present in the rooms
-func GetAllRoomNames(ah *AntHill) []string {
-	var roomNames []string
-	for _, room := range ah.FRooms {
-		roomNames = append(roomNames, room.Name)
+	moves := strings.Fields(step)
+	for i, move := range moves {
+		if rooms[strings.SplitN(move, "-", 2)[1]] {
+			moves[i] = Highlight(move)
+		}
 	}
-	return roomNames
+	return strings.Join(moves, " ")
 }
-
-//-FROOM
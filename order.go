@@ -0,0 +1,52 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The orders the moves of a turn can be printed in
+const (
+	OrderByAnt  = "ant"  // by ant number, as LexicalLsort does
+	OrderByPath = "path" // by the number of the path the ant follows, then by ant number
+	OrderByRoom = "room" // by the name of the room the ant moves into, then by ant number
+)
+
+// OrderMoves sorts the moves within every turn of a solution in the given order. Ants with the same key keep their ant number order
+func OrderMoves(solution *Solution, start, order string) []string {
+	paths := map[int]int{}
+	for _, itinerary := range Itineraries(solution, start) {
+		paths[itinerary.Ant] = itinerary.Path
+		if itinerary.Path == 0 {
+			// ants that were re-planned off their path come after the ants on the paths
+			paths[itinerary.Ant] = len(solution.Paths) + 1
+		}
+	}
+
+	moves := solution.Moves()
+	steps := make([]string, len(moves))
+	for i, step := range moves {
+		turn := strings.Fields(LexicalLsort(step))
+		sort.SliceStable(turn, func(a, b int) bool {
+			antA, roomA := splitMove(turn[a])
+			antB, roomB := splitMove(turn[b])
+			switch order {
+			case OrderByPath:
+				return paths[antA] < paths[antB]
+			case OrderByRoom:
+				return roomA < roomB
+			}
+			return false
+		})
+		steps[i] = strings.Join(turn, " ")
+	}
+	return steps
+}
+
+// splitMove splits an "Lx-room" move into the ant number and the room name
+func splitMove(move string) (int, string) {
+	parts := strings.SplitN(move[1:], "-", 2)
+	ant, _ := strconv.Atoi(parts[0])
+	return ant, parts[1]
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrderMoves(t *testing.T) {
+	g, _, err := ParseGraph(strings.NewReader("5\n##start\ns 0 0\na 1 0\nb 1 1\nc 2 1\n##end\ne 2 0\ns-a\na-e\ns-b\nb-c\nc-e"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		order string
+		moves string
+	}{
+		{OrderByAnt, "L1-a L3-b\nL1-e L2-a L3-c L5-b\nL2-e L3-e L4-a L5-c\nL4-e L5-e"},
+		{OrderByPath, "L1-a L3-b\nL1-e L2-a L3-c L5-b\nL2-e L4-a L3-e L5-c\nL4-e L5-e"},
+		{OrderByRoom, "L1-a L3-b\nL2-a L5-b L3-c L1-e\nL4-a L5-c L2-e L3-e\nL4-e L5-e"},
+	} {
+		// the same colony gives the same moves on every run
+		for i := 0; i < 3; i++ {
+			solution, err := Solve(g)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(OrderMoves(solution, g.StartRoomName, test.order), "\n"); got != test.moves {
+				t.Errorf("--order %v: got\n%v\nwant\n%v", test.order, got, test.moves)
+			}
+		}
+	}
+	if _, _, code := run(t, "--order", "turn", "example00.txt"); code != ExitUsage {
+		t.Errorf("--order turn: exit code %d, want %d", code, ExitUsage)
+	}
+}
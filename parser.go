@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Parser checks a colony and builds its graph one line at a time, so a colony is read exactly once
+// and every problem is found on the line that causes it
+type Parser struct {
+	graph   *Graph
+	coords  map[[2]int]bool
+	links   [][2]string     // links are added to the graph once the start and end rooms are known
+	linked  map[string]bool // every link, named by tunnelKey, to find duplicates
+	line    int
+	pending *CommandCall      // a command like ##start waiting for its room
+	meta    map[string]string // metadata for the next room or link
+
+	// Whitespace decides how the fields of a room are separated, task.md's single spaces by default
+	Whitespace WhitespacePolicy
+	// Commands are the ## commands the parser knows, DefaultCommands unless changed
+	Commands Commands
+	// CommandMode decides if unknown ## commands are ignored or rejected
+	CommandMode CommandMode
+}
+
+// ParseOptions changes how ParseGraphWith reads a colony, the zero value reads it as task.md describes it
+type ParseOptions struct {
+	Whitespace  WhitespacePolicy
+	Commands    Commands // nil means DefaultCommands
+	CommandMode CommandMode
+	// DiscardLines leaves the lines out of what ParseGraphWith returns, so a colony that is never printed isn't kept in memory
+	DiscardLines bool
+}
+
+// NewParser returns a parser that fills the given graph
+func NewParser(g *Graph) *Parser {
+	return &Parser{graph: g, coords: map[[2]int]bool{}, linked: map[string]bool{}, Commands: DefaultCommands()}
+}
+
+// newParserWith returns a parser that fills the given graph the way the options ask
+func newParserWith(g *Graph, options ParseOptions) *Parser {
+	p := NewParser(g)
+	p.Whitespace = options.Whitespace
+	p.CommandMode = options.CommandMode
+	if options.Commands != nil {
+		p.Commands = options.Commands
+	}
+	return p
+}
+
+// parseOptionFlags are the --whitespace and --commands flags of the commands that read a colony
+type parseOptionFlags struct {
+	whitespace *string
+	commands   *string
+}
+
+// addParseOptionFlags adds --whitespace and --commands to a command
+func addParseOptionFlags(flags *flag.FlagSet) *parseOptionFlags {
+	return &parseOptionFlags{
+		whitespace: flags.String("whitespace", "single", "how the fields of a room are separated: \"single\" spaces or \"runs\" of any whitespace"),
+		commands:   flags.String("commands", "spec", "unknown ## commands are ignored (\"spec\") or rejected (\"strict\")"),
+	}
+}
+
+// Options returns the parse options the flags ask for, the error names the wrong flag
+func (f *parseOptionFlags) Options() (ParseOptions, error) {
+	policy, err := ParseWhitespacePolicy(*f.whitespace)
+	if err != nil {
+		return ParseOptions{}, fmt.Errorf("--%v", err)
+	}
+	mode, err := ParseCommandMode(*f.commands)
+	if err != nil {
+		return ParseOptions{}, fmt.Errorf("--%v", err)
+	}
+	return ParseOptions{Whitespace: policy, CommandMode: mode}, nil
+}
+
+// fail builds the error for a problem on the current line
+func (p *Parser) fail(format string, args ...interface{}) error {
+	return invalidError{fmt.Sprintf("ERROR: invalid data format. %v (line %d)", fmt.Sprintf(format, args...), p.line)}
+}
+
+// ParseLine checks the next line of the colony and adds what it describes to the graph
+func (p *Parser) ParseLine(line string) error {
+	p.line++
+	g := p.graph
+	line = p.Whitespace.Trim(line)
+
+	// the first line is the number of ants
+	if p.line == 1 {
+		ants, err := strconv.Atoi(line)
+		if err != nil {
+			return p.fail("First line is not a number")
+		}
+		if ants <= 0 {
+			return p.fail("Number of ants is negative or zero")
+		}
+		g.Ants = ants
+		return nil
+	}
+	if len(line) < 3 {
+		return p.fail("Line is too short")
+	}
+
+	if strings.HasPrefix(line, "#") {
+		return p.parseCommand(line)
+	}
+
+	words := p.Whitespace.Fields(line)
+	switch {
+	case len(words) > 3:
+		return p.fail("3 or more spaces in a line are not allowed")
+	case len(words) > 1:
+		return p.parseRoom(words)
+	case strings.Contains(line, "-"):
+		return p.parseLink(words[0])
+	}
+	return p.fail("Line is neither a room nor a link")
+}
+
+// parseCommand runs a ## command from the registry and keeps #@key=value annotations for the next room or link,
+// any other line starting with # is a comment
+func (p *Parser) parseCommand(line string) error {
+	if key, value, ok := parseAnnotation(line); ok {
+		p.attach(key, value)
+		return nil
+	}
+	if !strings.HasPrefix(line, "##") {
+		return nil
+	}
+	name, args := splitCommand(line)
+	handler, ok := p.Commands[name]
+	if !ok {
+		if p.CommandMode == CommandsStrict {
+			return p.fail("Unknown command %v", name)
+		}
+		return nil
+	}
+	call := &CommandCall{Graph: p.graph, Name: name, Args: args, Text: line}
+	if err := handler(call); err != nil {
+		if strings.HasPrefix(err.Error(), "ERROR: ") {
+			return invalidError{fmt.Sprintf("%v (line %d)", err, p.line)}
+		}
+		return p.fail("%v", err)
+	}
+	if call.onRoom != nil {
+		if p.pending != nil {
+			return p.fail("%v must be followed by a room", p.pending.Name)
+		}
+		p.pending = call
+	}
+	for key, value := range call.meta {
+		p.attach(key, value)
+	}
+	return nil
+}
+
+// attach keeps metadata for the next room or link
+func (p *Parser) attach(key, value string) {
+	if p.meta == nil {
+		p.meta = map[string]string{}
+	}
+	p.meta[key] = value
+}
+
+// parseAnnotation reads a "#@key=value" comment. Anything else, a #@ comment without = or key included,
+// stays an ordinary comment
+func parseAnnotation(line string) (string, string, bool) {
+	if !strings.HasPrefix(line, "#@") {
+		return "", "", false
+	}
+	pair := strings.SplitN(line[2:], "=", 2)
+	if len(pair) != 2 || pair[0] == "" {
+		return "", "", false
+	}
+	return pair[0], pair[1], true
+}
+
+// parseRoom adds a "name x y" room to the graph
+func (p *Parser) parseRoom(words []string) error {
+	g := p.graph
+	if len(words) != 3 {
+		return p.fail("Room name or room coordinates invalid")
+	}
+	name := words[0]
+	if err := ValidateRoomName(name); err != nil {
+		return p.fail("Room name invalid, %v", err)
+	}
+	x, err := ParseCoordinate(words[1])
+	if err != nil {
+		return p.fail("Room coordinates invalid, %v", err)
+	}
+	y, err := ParseCoordinate(words[2])
+	if err != nil {
+		return p.fail("Room coordinates invalid, %v", err)
+	}
+	if len(p.links) > 0 {
+		return p.fail("Invalid connection, all connections have to be continuous in the end")
+	}
+	if g.getRoom(name) != nil {
+		return p.fail("Duplicate room names are not allowed (%v)", name)
+	}
+	if p.coords[[2]int{x, y}] {
+		return p.fail("Duplicate coordinates")
+	}
+	p.coords[[2]int{x, y}] = true
+	g.AddRoom(name)
+	g.Rooms[len(g.Rooms)-1].Meta, p.meta = p.meta, nil
+
+	if p.pending != nil {
+		onRoom := p.pending.onRoom
+		p.pending = nil
+		if err := onRoom(name); err != nil {
+			return p.fail("%v", err)
+		}
+	}
+	return nil
+}
+
+// parseLink checks a "room1-room2" link, it is added to the graph by Finish
+func (p *Parser) parseLink(line string) error {
+	if p.pending != nil {
+		return p.fail("%v must be followed by a room", p.pending.Name)
+	}
+	names := strings.Split(line, "-")
+	if len(names) != 2 {
+		return p.fail("2 or more dashes in a line are not allowed")
+	}
+	if names[0] == names[1] {
+		return p.fail("You have a connection from the same room to same room")
+	}
+	for _, name := range names {
+		if p.graph.getRoom(name) == nil {
+			return p.fail("Room doesn't exist (%v)", name)
+		}
+	}
+	key := tunnelKey(names[0], names[1])
+	if p.linked[key] {
+		return p.fail("Duplicate Link (%v --- %v)", names[0], names[1])
+	}
+	p.linked[key] = true
+	p.links = append(p.links, [2]string{names[0], names[1]})
+	if p.meta != nil {
+		if p.graph.LinkMeta == nil {
+			p.graph.LinkMeta = map[string]map[string]string{}
+		}
+		p.graph.LinkMeta[key], p.meta = p.meta, nil
+	}
+	return nil
+}
+
+// Finish runs the checks that need the whole colony and links the rooms
+func (p *Parser) Finish() error {
+	g := p.graph
+	if p.pending != nil {
+		return invalidError{fmt.Sprintf("ERROR: invalid data format. %v must be followed by a room", p.pending.Name)}
+	}
+	if p.line == 0 {
+		return invalidError{"ERROR: invalid data format. Not enough lines"}
+	}
+	if g.StartRoomName == "" || g.EndRoomName == "" {
+		return invalidError{"ERROR: invalid data format. No ##start or ##end"}
+	}
+	for _, link := range p.links {
+		if err := g.AddLinks(link[0], link[1]); err != nil {
+			return err
+		}
+	}
+	linked := map[string]bool{}
+	for _, link := range p.links {
+		linked[link[0]], linked[link[1]] = true, true
+	}
+	for _, room := range g.Rooms {
+		if !linked[room.Roomname] {
+			return invalidError{fmt.Sprintf("ERROR: invalid data format. The room \"%v\" is not connected to the anthill", room.Roomname)}
+		}
+	}
+	if err := checkCheckpoints(g); err != nil {
+		return err
+	}
+	return checkBlocks(g)
+}
+
+// ParseGraph reads a colony from r one line at a time and builds its graph as it goes. Lines can be of any length.
+// The lines are returned as well, without their line endings, because the output starts with the colony as it was read
+func ParseGraph(r io.Reader) (*Graph, []string, error) {
+	return ParseGraphWith(r, ParseOptions{})
+}
+
+// ParseGraphWith is ParseGraph with the whitespace policy, ## commands and handling of the lines given by the options
+func ParseGraphWith(r io.Reader, options ParseOptions) (*Graph, []string, error) {
+	g := &Graph{Rooms: []*Room{}}
+	p := newParserWith(g, options)
+	var lines []string
+	err := readLines(r, func(line string) error {
+		if !options.DiscardLines {
+			lines = append(lines, strings.TrimSuffix(line, "\r"))
+		}
+		return p.ParseLine(line)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := p.Finish(); err != nil {
+		return nil, nil, err
+	}
+	return g, lines, nil
+}
+
+// ReadLines reads every line from r, split on "\n" only, so that a trailing newline shows up as an empty last line
+func ReadLines(r io.Reader) ([]string, error) {
+	var lines []string
+	err := readLines(r, func(line string) error {
+		lines = append(lines, line)
+		return nil
+	})
+	return lines, err
+}
+
+// readLines hands every line of r to handle as soon as it has been read, however long it is
+func readLines(r io.Reader, handle func(line string) error) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if err := handle(strings.TrimSuffix(line, "\n")); err != nil {
+			return err
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
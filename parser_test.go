@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseGraphLongLines(t *testing.T) {
+	comment := "#" + strings.Repeat("x", 1<<20)
+	name := strings.Repeat("r", 100000)
+	colony := "1\n" + comment + "\n##start\ns 0 0\n" + name + " 1 1\n##end\ne 2 2\ns-" + name + "\n" + name + "-e"
+	g, lines, err := ParseGraph(strings.NewReader(colony))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 9 || lines[1] != comment {
+		t.Errorf("the lines weren't kept as they were read")
+	}
+	if g.getRoom(name) == nil {
+		t.Errorf("the room with a long name is missing")
+	}
+	// a colony that is never printed isn't kept
+	g, lines, err = ParseGraphWith(strings.NewReader(colony), ParseOptions{DiscardLines: true})
+	if err != nil || lines != nil || g.getRoom(name) == nil {
+		t.Errorf("discarding the lines: kept %d lines, %v", len(lines), err)
+	}
+}
+
+func TestAddLinks(t *testing.T) {
+	g := &Graph{Rooms: []*Room{}, StartRoomName: "s", EndRoomName: "e"}
+	for _, name := range []string{"s", "a", "e"} {
+		g.AddRoom(name)
+	}
+	for _, test := range []struct {
+		from, to string
+		err      string // empty when the link is added
+	}{
+		{"s", "a", ""},
+		{"a", "e", ""},
+		{"a", "s", "ERROR: invalid data format. Duplicate Link (a --- s)"},
+		{"e", "a", "ERROR: invalid data format. Duplicate Link (e --- a)"},
+		{"a", "x", "ERROR: invalid data format. Room doesn't exist (a-x)"},
+	} {
+		err := g.AddLinks(test.from, test.to)
+		if test.err == "" && err != nil || test.err != "" && (err == nil || err.Error() != test.err || ExitCode(err) != ExitInvalid) {
+			t.Errorf("%v-%v: got %v, want %q", test.from, test.to, err, test.err)
+		}
+	}
+}
+
+func TestParseGraphStopsAtTheFirstProblem(t *testing.T) {
+	// the problem is found on its own line, even when the colony goes on for much longer
+	colony := "1\n##start\ns 0 0\n##end\ne 1 1\ns-x\n" + strings.Repeat("#comment\n", 10000) + "s-e"
+	if _, _, err := ParseGraph(strings.NewReader(colony)); err == nil || !strings.HasSuffix(err.Error(), "Room doesn't exist (x) (line 6)") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestSolveFromStdin(t *testing.T) {
+	colony, err := os.Open("example00.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer colony.Close()
+	stdin := os.Stdin
+	os.Stdin = colony
+	defer func() { os.Stdin = stdin }()
+
+	stdout, _, code := run(t, "--quiet", "-")
+	want, err := os.ReadFile(filepath.Join("testdata", "example00.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != ExitOK || stdout != string(want) {
+		t.Errorf("solving stdin printed\n%v\nwith exit code %d, want\n%s", stdout, code, want)
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// ServeRequest is the JSON body of a request, the colony in the official format and, for /verify, the moves of every turn
+type ServeRequest struct {
+	Colony string   `json:"colony"`
+	Moves  []string `json:"moves,omitempty"`
+}
+
+// ValidateResponse is the answer to /validate: the first error that makes the colony unusable, if any,
+// and every finding of the linter
+type ValidateResponse struct {
+	Valid    bool      `json:"valid"`
+	Error    string    `json:"error,omitempty"`
+	Findings []Finding `json:"findings"`
+}
+
+// VerifyResponse is the answer to /verify
+type VerifyResponse struct {
+	Valid bool   `json:"valid"`
+	Turns int    `json:"turns"`
+	Error string `json:"error,omitempty"`
+}
+
+// Server answers solve, validate and verify requests over HTTP with the same parser and solver as the command line
+type Server struct {
+	MaxBytes int64         // largest request body accepted
+	Timeout  time.Duration // time a request may take, waiting for a free solver included
+	// TurnDelay is the time /stream waits between two turns, unless the request asks for another delay
+	TurnDelay time.Duration
+	slots     chan struct{} // one slot per colony that can be solved at the same time
+}
+
+// NewServer returns a server that solves at most workers colonies at the same time
+func NewServer(maxBytes int64, timeout time.Duration, workers int) *Server {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Server{MaxBytes: maxBytes, Timeout: timeout, slots: make(chan struct{}, workers)}
+}
+
+// Handler routes the requests of the server
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "only GET is allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	mux.Handle("/solve", s.post(s.solve))
+	mux.Handle("/validate", s.post(s.validate))
+	mux.Handle("/verify", s.post(s.verify))
+	mux.HandleFunc("/stream", s.stream)
+	return mux
+}
+
+// post wraps a handler with the checks every POST endpoint shares: the method, the size of the body,
+// the timeout and the number of colonies being solved at once
+func (s *Server) post(handle func(w http.ResponseWriter, r *http.Request, request ServeRequest, isJSON bool)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		request, isJSON, err := s.readRequest(w, r)
+		if err != nil {
+			return
+		}
+		isJSON = isJSON || strings.Contains(r.Header.Get("Accept"), "application/json")
+		ctx, cancel := context.WithTimeout(r.Context(), s.Timeout)
+		defer cancel()
+		if err := s.acquire(ctx); err != nil {
+			s.writeTimeout(w, isJSON)
+			return
+		}
+		defer s.release()
+		handle(w, r.WithContext(ctx), request, isJSON)
+	})
+}
+
+// acquire waits for a free solver slot until the context is done, release frees it again
+func (s *Server) acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Server) release() {
+	<-s.slots
+}
+
+// timeoutError tells that the server's timeout ran out, other errors are returned as they are
+func (s *Server) timeoutError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("no answer within %v", s.Timeout)
+	}
+	return err
+}
+
+// writeTimeout answers a request that got no free solver or no solution within the server's timeout
+// with 503 Service Unavailable
+func (s *Server) writeTimeout(w http.ResponseWriter, isJSON bool) {
+	message := fmt.Sprintf("no answer within %v", s.Timeout)
+	if isJSON {
+		writeServeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": message})
+		return
+	}
+	http.Error(w, message, http.StatusServiceUnavailable)
+}
+
+// readRequest reads the body of a request, answering with an error when it is too large or malformed
+func (s *Server) readRequest(w http.ResponseWriter, r *http.Request) (ServeRequest, bool, error) {
+	request, isJSON, err := readServeRequest(http.MaxBytesReader(w, r.Body, s.MaxBytes), r.Header.Get("Content-Type"), r.URL.Path == "/verify")
+	if err != nil {
+		status := http.StatusBadRequest
+		if strings.Contains(err.Error(), "request body too large") {
+			status = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), status)
+	}
+	return request, isJSON, err
+}
+
+// readServeRequest reads a JSON request, or a colony as plain text. A plain text /verify request is the official
+// output: the colony, an empty line and the moves
+func readServeRequest(body io.Reader, contentType string, withMoves bool) (ServeRequest, bool, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return ServeRequest{}, false, err
+	}
+	if strings.HasPrefix(contentType, "application/json") {
+		var request ServeRequest
+		if err := json.Unmarshal(data, &request); err != nil {
+			return ServeRequest{}, true, fmt.Errorf("invalid JSON request: %v", err)
+		}
+		return request, true, nil
+	}
+	if !withMoves {
+		return ServeRequest{Colony: strings.ReplaceAll(string(data), "\r\n", "\n")}, false, nil
+	}
+	colony, moves, err := SplitOutput(string(data))
+	return ServeRequest{Colony: colony, Moves: moves}, false, err
+}
+
+// solve answers with the official output, streamed turn by turn, or with the JSON report.
+// The solve is cancelled when the request's time is up, so that its slot is freed for the next request
+func (s *Server) solve(w http.ResponseWriter, r *http.Request, request ServeRequest, isJSON bool) {
+	solved, err := SolveColony(r.Context(), strings.NewReader(request.Colony), ParseOptions{}, nil)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		s.writeTimeout(w, isJSON)
+		return
+	case err != nil:
+		writeServeError(w, err, isJSON)
+		return
+	}
+	if isJSON {
+		solved.Report.AddSolution(solved.Solution, solved.Graph.StartRoomName)
+		w.Header().Set("Content-Type", "application/json")
+		solved.Report.Write(w)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	solved.WriteOutput(w)
+}
+
+// validate answers whether the colony can be solved, with every finding of the linter
+func (s *Server) validate(w http.ResponseWriter, r *http.Request, request ServeRequest, isJSON bool) {
+	response := ValidateResponse{Valid: true, Findings: Lint(strings.Split(request.Colony, "\n"), ParseOptions{})}
+	if response.Findings == nil {
+		response.Findings = []Finding{}
+	}
+	if _, err := ReadColony(strings.NewReader(request.Colony), ParseOptions{}); err != nil {
+		response.Valid, response.Error = false, err.Error()
+	}
+	writeServeJSON(w, http.StatusOK, response)
+}
+
+// verify answers whether the moves are a legal schedule for the colony
+func (s *Server) verify(w http.ResponseWriter, r *http.Request, request ServeRequest, isJSON bool) {
+	g, _, err := ParseGraph(strings.NewReader(request.Colony))
+	if err != nil {
+		writeServeError(w, err, true)
+		return
+	}
+	response := VerifyResponse{Valid: true, Turns: len(request.Moves)}
+	if err := Verify(g, request.Moves); err != nil {
+		response.Valid, response.Error = false, err.Error()
+	}
+	writeServeJSON(w, http.StatusOK, response)
+}
+
+// writeServeError answers a colony that can't be solved with 422 Unprocessable Entity
+func writeServeError(w http.ResponseWriter, err error, isJSON bool) {
+	if isJSON {
+		writeServeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return
+	}
+	http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+}
+
+// writeServeJSON answers with a JSON body
+func writeServeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// RunServe runs the serve command, it only returns when the server stops
+func RunServe(args []string) int {
+	flags := newFlagSet("serve", "[flags]", "Starts an HTTP server answering /solve, /validate, /verify, /stream and /healthz.")
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	maxBytes := flags.Int64("max-bytes", 10<<20, "largest request body accepted, in bytes")
+	timeout := flags.Duration("timeout", 30*time.Second, "time a request may take")
+	workers := flags.Int("workers", runtime.NumCPU(), "number of colonies solved at the same time")
+	turnDelay := flags.Duration("turn-delay", 500*time.Millisecond, "time /stream waits between two turns")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	handler := NewServer(*maxBytes, *timeout, *workers)
+	handler.TurnDelay = *turnDelay
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           handler.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("listening on http://%v", *addr)
+	if err := server.ListenAndServe(); err != nil {
+		log.Println(err)
+		return ExitInternal
+	}
+	return ExitOK
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// post sends a request to the server and returns the status and body of the answer
+func post(t *testing.T, server *httptest.Server, path, contentType, body string) (int, string) {
+	t.Helper()
+	status, answer, err := send(server, path, contentType, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return status, answer
+}
+
+// send is post for goroutines, which can't stop the test
+func send(server *httptest.Server, path, contentType, body string) (int, string, error) {
+	response, err := http.Post(server.URL+path, contentType, strings.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	return response.StatusCode, string(data), err
+}
+
+func TestServe(t *testing.T) {
+	server := httptest.NewServer(NewServer(1<<20, 10*time.Second, 4).Handler())
+	defer server.Close()
+	colony, err := os.ReadFile("example00.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moves, err := os.ReadFile("testdata/example00.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := string(colony) + "\n\n" + string(moves)
+
+	response, err := http.Get(server.URL + "/healthz")
+	if err != nil || response.StatusCode != http.StatusOK {
+		t.Fatalf("healthz: %v %v", response, err)
+	}
+	response.Body.Close()
+
+	// the text answer is the official output, the same as the command line prints
+	status, body := post(t, server, "/solve", "text/plain", string(colony))
+	if status != http.StatusOK || body != want {
+		t.Errorf("solve: got %d\n%v\nwant\n%v", status, body, want)
+	}
+
+	request, _ := json.Marshal(ServeRequest{Colony: string(colony)})
+	status, body = post(t, server, "/solve", "application/json", string(request))
+	var report JSONReport
+	if err := json.Unmarshal([]byte(body), &report); status != http.StatusOK || err != nil || report.Turns != 6 {
+		t.Errorf("solve JSON: got %d %v", status, body)
+	}
+
+	status, body = post(t, server, "/solve", "text/plain", "0\n##start\na 0 0")
+	if status != http.StatusUnprocessableEntity || !strings.Contains(body, "Number of ants") {
+		t.Errorf("solve invalid colony: got %d %v", status, body)
+	}
+
+	status, body = post(t, server, "/validate", "text/plain", "2\n##start\na 0 0\n##end\nb 1 1\nc 2 2\na-b")
+	var validation ValidateResponse
+	if err := json.Unmarshal([]byte(body), &validation); status != http.StatusOK || err != nil || validation.Valid || len(validation.Findings) == 0 {
+		t.Errorf("validate: got %d %v", status, body)
+	}
+
+	status, body = post(t, server, "/verify", "text/plain", want)
+	var verification VerifyResponse
+	if err := json.Unmarshal([]byte(body), &verification); status != http.StatusOK || err != nil || !verification.Valid || verification.Turns != 6 {
+		t.Errorf("verify: got %d %v", status, body)
+	}
+	request, _ = json.Marshal(ServeRequest{Colony: string(colony), Moves: []string{"L1-3 L2-3"}})
+	status, body = post(t, server, "/verify", "application/json", string(request))
+	if err := json.Unmarshal([]byte(body), &verification); status != http.StatusOK || err != nil || verification.Valid {
+		t.Errorf("verify illegal moves: got %d %v", status, body)
+	}
+
+	status, _ = post(t, server, "/solve", "text/plain", strings.Repeat("#", 2<<20))
+	if status != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized request: got %d", status)
+	}
+	if response, err := http.Get(server.URL + "/solve"); err != nil || response.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /solve: %v %v", response, err)
+	}
+}
+
+func TestServeConcurrently(t *testing.T) {
+	server := httptest.NewServer(NewServer(1<<20, 30*time.Second, 4).Handler())
+	defer server.Close()
+	var wg sync.WaitGroup
+	for _, file := range []string{"example00.txt", "example01.txt", "example02.txt", "example03.txt", "example04.txt", "example05.txt"} {
+		colony, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		moves, err := os.ReadFile("testdata/" + strings.TrimSuffix(file, ".txt") + ".golden")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := string(colony) + "\n\n" + string(moves)
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(file string) {
+				defer wg.Done()
+				status, body, err := send(server, "/solve", "text/plain", string(colony))
+				if err != nil {
+					t.Errorf("%v: %v", file, err)
+				} else if status != http.StatusOK || body != want {
+					t.Errorf("%v: got %d, the answer differs from the golden file", file, status)
+				}
+			}(file)
+		}
+	}
+	wg.Wait()
+}
+
+func TestServeTimeout(t *testing.T) {
+	// a single solver slot, held by a colony the path search can't finish in time
+	server := httptest.NewServer(NewServer(1<<20, 300*time.Millisecond, 1).Handler())
+	defer server.Close()
+	slow := strings.Join(GenerateColony(10, 300, 150, 1), "\n")
+	colony, err := os.ReadFile("example00.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := time.Now()
+	status, body := post(t, server, "/solve", "text/plain", slow)
+	if status != http.StatusServiceUnavailable || !strings.HasPrefix(body, "no answer within 300ms") {
+		t.Errorf("slow colony: got %d %q", status, body)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("the timeout took %v", elapsed)
+	}
+	// the cancelled solve gave its slot back
+	if status, _ := post(t, server, "/solve", "text/plain", string(colony)); status != http.StatusOK {
+		t.Errorf("the next colony got %d", status)
+	}
+	status, body = post(t, server, "/stream?delay=0s", "text/plain", slow)
+	if status != http.StatusOK || !strings.Contains(body, "event: error\ndata: no answer within 300ms") {
+		t.Errorf("slow stream: got %d %q", status, body)
+	}
+}
+
+func TestServeStream(t *testing.T) {
+	server := httptest.NewServer(NewServer(1<<20, 10*time.Second, 2).Handler())
+	defer server.Close()
+	colony, err := os.ReadFile("example00.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	moves, err := os.ReadFile("testdata/example00.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the moves of every turn come as one event, in the order the command line prints them
+	started := time.Now()
+	status, body := post(t, server, "/stream?delay=20ms", "text/plain", string(colony))
+	if status != http.StatusOK {
+		t.Fatalf("stream: got %d %v", status, body)
+	}
+	if elapsed := time.Since(started); elapsed < 100*time.Millisecond {
+		t.Errorf("the 6 turns took %v, want at least 5 delays of 20ms", elapsed)
+	}
+	events := strings.Split(strings.TrimSuffix(body, "\n\n"), "\n\n")
+	if len(events) != 8 || !strings.HasPrefix(events[0], "event: colony\ndata: {") || events[7] != "event: done\ndata: {\"turns\":6,\"ants\":4}" {
+		t.Fatalf("unexpected events:\n%v", body)
+	}
+	for i, line := range strings.Split(strings.TrimSuffix(string(moves), "\n"), "\n") {
+		if want := fmt.Sprintf("event: turn\nid: %d\ndata: %v", i+1, line); events[i+1] != want {
+			t.Errorf("turn %d: got %q, want %q", i+1, events[i+1], want)
+		}
+	}
+
+	// a browser's EventSource sends the colony as a GET parameter
+	response, err := http.Get(server.URL + "/stream?delay=0s&colony=" + url.QueryEscape("0\n##start\na 0 0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	data, _ := io.ReadAll(response.Body)
+	if !strings.HasPrefix(string(data), "event: error\ndata: ERROR: invalid data format. Number of ants") {
+		t.Errorf("stream invalid colony: got %q", data)
+	}
+}
+
+func TestTurnWriterStopsWhenTheClientHasGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/stream", nil).WithContext(ctx)
+	turns := &turnWriter{w: recorder, flusher: recorder, request: request}
+	solution := &Solution{Ants: 1000000, Paths: []string{"a-e", "b-c-e"}}
+	if err := solution.WriteMoves(turns); err == nil {
+		t.Errorf("the moves were written to a client that has gone")
+	}
+	if turns.turn != 0 || recorder.Body.Len() != 0 {
+		t.Errorf("%d turns sent after the client had gone", turns.turn)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// turnWriter sends every line the solution writes as one Server-Sent Event, waiting delay between turns
+type turnWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	request *http.Request
+	delay   time.Duration
+	turn    int
+	partial []byte // the start of a line whose end hasn't been written yet
+}
+
+// Write sends the complete lines of p as turn events, it fails once the client has gone
+func (t *turnWriter) Write(p []byte) (int, error) {
+	t.partial = append(t.partial, p...)
+	for {
+		if err := t.request.Context().Err(); err != nil {
+			return 0, err
+		}
+		end := bytes.IndexByte(t.partial, '\n')
+		if end < 0 {
+			return len(p), nil
+		}
+		line := string(t.partial[:end])
+		t.partial = t.partial[end+1:]
+		if t.turn > 0 && t.delay > 0 {
+			select {
+			case <-time.After(t.delay):
+			case <-t.request.Context().Done():
+				return 0, t.request.Context().Err()
+			}
+		}
+		t.turn++
+		if err := writeEvent(t.w, "turn", t.turn, line); err != nil {
+			return 0, err
+		}
+		t.flusher.Flush()
+	}
+}
+
+// writeEvent writes one Server-Sent Event, an id of 0 is left out
+func writeEvent(w http.ResponseWriter, event string, id int, data string) error {
+	text := fmt.Sprintf("event: %v\n", event)
+	if id > 0 {
+		text += fmt.Sprintf("id: %d\n", id)
+	}
+	_, err := fmt.Fprintf(w, "%vdata: %v\n\n", text, data)
+	return err
+}
+
+// stream solves a colony and sends it as Server-Sent Events: a colony event with the JSON report of the rooms and links,
+// one turn event per turn with the moves of that turn, then a done event with the number of turns, or an error event.
+// Browsers can't POST with EventSource, so the colony can also be given as the colony parameter of a GET request
+func (s *Server) stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	var request ServeRequest
+	switch r.Method {
+	case http.MethodGet:
+		request.Colony = r.URL.Query().Get("colony")
+		if int64(len(request.Colony)) > s.MaxBytes {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+	case http.MethodPost:
+		var err error
+		if request, _, err = s.readRequest(w, r); err != nil {
+			return
+		}
+	default:
+		http.Error(w, "only GET and POST are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	delay := s.TurnDelay
+	if value := r.URL.Query().Get("delay"); value != "" {
+		var err error
+		if delay, err = time.ParseDuration(value); err != nil || delay < 0 {
+			http.Error(w, fmt.Sprintf("invalid delay %q", value), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	solved, err := s.solveWithin(r, request.Colony)
+	if err != nil {
+		writeEvent(w, "error", 0, strings.ReplaceAll(err.Error(), "\n", " "))
+		flusher.Flush()
+		return
+	}
+	data, _ := json.Marshal(solved.Report)
+	if err := writeEvent(w, "colony", 0, string(data)); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	turns := &turnWriter{w: w, flusher: flusher, request: r, delay: delay}
+	if err := solved.Solution.WriteMoves(turns); err != nil {
+		return
+	}
+	writeEvent(w, "done", 0, fmt.Sprintf(`{"turns":%d,"ants":%d}`, solved.Solution.Turns, solved.Graph.Ants))
+	flusher.Flush()
+}
+
+// solveWithin parses and solves a colony with one of the server's solver slots. The solve is cancelled after
+// the server's timeout, which frees the slot
+func (s *Server) solveWithin(r *http.Request, colony string) (*SolvedColony, error) {
+	ctx, cancel := context.WithTimeout(r.Context(), s.Timeout)
+	defer cancel()
+	if err := s.acquire(ctx); err != nil {
+		return nil, s.timeoutError(err)
+	}
+	defer s.release()
+	solved, err := SolveColony(ctx, strings.NewReader(colony), ParseOptions{}, nil)
+	if err != nil {
+		return nil, s.timeoutError(err)
+	}
+	return solved, nil
+}
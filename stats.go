@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Stats describes the shape of a colony, so that maps can be classified before they are run
+type Stats struct {
+	Rooms         int
+	Links         int
+	Degrees       map[int]int // how many rooms have each number of links
+	Components    []int       // size of every connected component, largest first
+	Distance      int         // fewest links from start to end, -1 when end can't be reached
+	DisjointPaths int         // most paths from start to end that share no room
+	Articulations []string    // rooms every path from start to end goes through
+	Bridges       []string    // links every path from start to end goes through
+	Bottlenecks   []string    // fewest rooms that cut every path from start to end
+	MinX          int
+	MinY          int
+	MaxX          int
+	MaxY          int
+}
+
+// ColonyStats computes the statistics of a colony that has been parsed without errors
+func ColonyStats(c *Colony) Stats {
+	neighbours := c.Neighbours()
+	s := Stats{
+		Rooms:   len(neighbours),
+		Links:   countLinks(neighbours),
+		Degrees: map[int]int{},
+	}
+	for _, names := range neighbours {
+		s.Degrees[len(names)]++
+	}
+
+	seen := map[string]bool{}
+	for _, room := range c.Rooms {
+		if seen[room.Name] {
+			continue
+		}
+		component := Reachable(neighbours, room.Name)
+		for name := range component {
+			seen[name] = true
+		}
+		s.Components = append(s.Components, len(component))
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(s.Components)))
+
+	s.Distance = distance(neighbours, c.Start, c.End, "", "")
+	if s.Distance >= 0 {
+		s.DisjointPaths = MaxDisjointPaths(neighbours, c.Start, c.End)
+		s.Bottlenecks = MinVertexCut(neighbours, c.Start, c.End)
+		// only rooms and links on the corridor between start and end can cut it
+		useful := UsefulRooms(neighbours, c.Start, c.End)
+		for room := range useful {
+			if room != c.Start && room != c.End && distance(neighbours, c.Start, c.End, room, "") < 0 {
+				s.Articulations = append(s.Articulations, room)
+			}
+			for _, name := range neighbours[room] {
+				if room < name && useful[name] && distance(neighbours, c.Start, c.End, "", tunnelKey(room, name)) < 0 {
+					s.Bridges = append(s.Bridges, tunnelKey(room, name))
+				}
+			}
+		}
+		sort.Strings(s.Articulations)
+		sort.Strings(s.Bridges)
+	}
+
+	for i, room := range c.Rooms {
+		if i == 0 || room.X < s.MinX {
+			s.MinX = room.X
+		}
+		if i == 0 || room.Y < s.MinY {
+			s.MinY = room.Y
+		}
+		if i == 0 || room.X > s.MaxX {
+			s.MaxX = room.X
+		}
+		if i == 0 || room.Y > s.MaxY {
+			s.MaxY = room.Y
+		}
+	}
+	return s
+}
+
+// distance counts the fewest links from start to end without going through the skipped room or link, -1 when end can't be reached
+func distance(neighbours map[string][]string, start, end, skipRoom, skipLink string) int {
+	distances := map[string]int{start: 0}
+	queue := []string{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == end {
+			return distances[current]
+		}
+		for _, next := range neighbours[current] {
+			if _, seen := distances[next]; seen || next == skipRoom || tunnelKey(current, next) == skipLink {
+				continue
+			}
+			distances[next] = distances[current] + 1
+			queue = append(queue, next)
+		}
+	}
+	return -1
+}
+
+// Print writes the statistics one per line
+func (s Stats) Print(w io.Writer) {
+	fmt.Fprintf(w, "rooms: %d\n", s.Rooms)
+	fmt.Fprintf(w, "links: %d\n", s.Links)
+	degrees := []int{}
+	for degree := range s.Degrees {
+		degrees = append(degrees, degree)
+	}
+	sort.Ints(degrees)
+	distribution := []string{}
+	for _, degree := range degrees {
+		distribution = append(distribution, fmt.Sprintf("%d:%d", degree, s.Degrees[degree]))
+	}
+	fmt.Fprintf(w, "degree distribution (links:rooms): %v\n", strings.Join(distribution, " "))
+	sizes := []string{}
+	for _, size := range s.Components {
+		sizes = append(sizes, fmt.Sprint(size))
+	}
+	fmt.Fprintf(w, "connected components: %d (sizes %v)\n", len(s.Components), strings.Join(sizes, " "))
+	if s.Distance < 0 {
+		fmt.Fprintln(w, "shortest start-end distance: unreachable")
+	} else {
+		fmt.Fprintf(w, "shortest start-end distance: %d links\n", s.Distance)
+	}
+	fmt.Fprintf(w, "vertex-disjoint start-end paths: %d\n", s.DisjointPaths)
+	fmt.Fprintf(w, "bottleneck rooms (minimum cut): %v\n", listOrNone(s.Bottlenecks))
+	fmt.Fprintf(w, "articulation points: %v\n", listOrNone(s.Articulations))
+	fmt.Fprintf(w, "bridges: %v\n", listOrNone(s.Bridges))
+	fmt.Fprintf(w, "bounding box: %d %d to %d %d\n", s.MinX, s.MinY, s.MaxX, s.MaxY)
+}
+
+// listOrNone joins the names with spaces, or says "none" when there are none
+func listOrNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, " ")
+}
+
+// RunStats prints the statistics of a colony file and returns the exit code
+func RunStats(args []string) int {
+	flags := newFlagSet("stats", "[flags] <filename>", "Prints the counts, degrees, components, distances, bottlenecks and bounding box of a colony.")
+	parseOptions := addParseOptionFlags(flags)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitUsage
+	}
+	options, err := parseOptions.Options()
+	if err != nil {
+		fmt.Println(err)
+		return ExitUsage
+	}
+	args = flags.Args()
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		fmt.Println(err)
+		return ExitUsage
+	}
+	lines := strings.Split(string(data), "\n")
+	c, findings := ParseColony(lines, options)
+	findings = checkWithParser(lines, findings, options)
+	failed := false
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			PrintFinding(os.Stdout, args[0], finding)
+			failed = true
+		}
+	}
+	if failed {
+		return ExitInvalid
+	}
+	ColonyStats(c).Print(os.Stdout)
+	return ExitOK
+}
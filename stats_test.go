@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestColonyStats(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		colony string
+		stats  string
+	}{
+		{
+			"one corridor",
+			"1\n##start\ns 0 0\na 1 0\nb 1 1\nc 2 0\n##end\ne 3 0\nx 9 9\ny 8 -1\ns-a\ns-b\na-c\nb-c\nc-e\nx-y",
+			`rooms: 7
+links: 6
+degree distribution (links:rooms): 1:3 2:3 3:1
+connected components: 2 (sizes 5 2)
+shortest start-end distance: 3 links
+vertex-disjoint start-end paths: 1
+bottleneck rooms (minimum cut): c
+articulation points: c
+bridges: c-e
+bounding box: 0 -1 to 9 9
+`,
+		},
+		{
+			"two disjoint paths",
+			"1\n##start\ns 0 0\na 1 0\nb 1 1\n##end\ne 3 0\ns-a\ns-b\na-e\nb-e",
+			`rooms: 4
+links: 4
+degree distribution (links:rooms): 2:4
+connected components: 1 (sizes 4)
+shortest start-end distance: 2 links
+vertex-disjoint start-end paths: 2
+bottleneck rooms (minimum cut): a b
+articulation points: none
+bridges: none
+bounding box: 0 0 to 3 1
+`,
+		},
+		{
+			"unreachable end",
+			"1\n##start\ns 0 0\na 1 0\n##end\ne 3 0\nb 1 1\ns-a\ne-b",
+			`rooms: 4
+links: 2
+degree distribution (links:rooms): 1:4
+connected components: 2 (sizes 2 2)
+shortest start-end distance: unreachable
+vertex-disjoint start-end paths: 0
+bottleneck rooms (minimum cut): none
+articulation points: none
+bridges: none
+bounding box: 0 0 to 3 1
+`,
+		},
+	} {
+		c, findings := ParseColony(strings.Split(test.colony, "\n"), ParseOptions{})
+		if hasErrors(findings) {
+			t.Errorf("%v: %v", test.name, findings)
+			continue
+		}
+		var out bytes.Buffer
+		ColonyStats(c).Print(&out)
+		if out.String() != test.stats {
+			t.Errorf("%v: got\n%v\nwant\n%v", test.name, out.String(), test.stats)
+		}
+	}
+}
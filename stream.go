@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// antFeeder hands the ants to the paths one by one in the same order distributeAnts does,
+// without keeping the queue of every path in memory
+type antFeeder struct {
+	lengths []int // moves needed to walk every path
+	counts  []int // ants handed to every path so far
+	next    int   // number of the next ant
+	ants    int
+}
+
+func newAntFeeder(n int, pathLists [][]string) *antFeeder {
+	f := &antFeeder{lengths: make([]int, len(pathLists)), counts: make([]int, len(pathLists)), next: 1, ants: n}
+	for i, path := range pathLists {
+		f.lengths[i] = len(path)
+	}
+	return f
+}
+
+func (f *antFeeder) done() bool {
+	return f.next > f.ants
+}
+
+// feed hands the next ant to the path where it will arrive the soonest and returns that path and the ant
+func (f *antFeeder) feed() (int, int) {
+	minStepsIndex := 0
+	for j := range f.lengths {
+		if f.lengths[j]+f.counts[j] < f.lengths[minStepsIndex]+f.counts[minStepsIndex] {
+			minStepsIndex = j
+		}
+	}
+	f.counts[minStepsIndex]++
+	f.next++
+	return minStepsIndex, f.next - 1
+}
+
+// TurnCount counts the turns AntSender needs to move n ants along the paths, without building the moves
+func TurnCount(n int, pathList []string) int {
+	if len(pathList) == 0 {
+		return 0
+	}
+	pathLists := make([][]string, len(pathList))
+	for i, path := range pathList {
+		pathLists[i] = strings.Split(path, "-")
+	}
+	f := newAntFeeder(n, pathLists)
+	for !f.done() {
+		f.feed()
+	}
+	turns := 0
+	for i, count := range f.counts {
+		if count > 0 && count-1+f.lengths[i] > turns {
+			turns = count - 1 + f.lengths[i]
+		}
+	}
+	return turns
+}
+
+// AntsPerPath counts how many of the n ants AntSender sends along every path
+func AntsPerPath(n int, pathList []string) []int {
+	pathLists := make([][]string, len(pathList))
+	for i, path := range pathList {
+		pathLists[i] = strings.Split(path, "-")
+	}
+	f := newAntFeeder(n, pathLists)
+	for !f.done() {
+		f.feed()
+	}
+	return f.counts
+}
+
+// StreamMoves writes the same lines as AntSender, one turn at a time. Only the ants that are on their way are kept in memory,
+// so the memory needed grows with the number and length of the paths instead of with the number of ants.
+// It stops at the first write that fails, so nothing more is computed for a reader that has gone
+func StreamMoves(w io.Writer, n int, pathList []string) error {
+	if len(pathList) == 0 {
+		return nil
+	}
+	pathLists := make([][]string, len(pathList))
+	for i, path := range pathList {
+		pathLists[i] = strings.Split(path, "-")
+	}
+	f := newAntFeeder(n, pathLists)
+	// window[p] holds the ants on path p from queue position first[p] onward
+	window := make([][]int, len(pathLists))
+	first := make([]int, len(pathLists))
+
+	out := bufio.NewWriter(w)
+	type move struct {
+		ant  int
+		room string
+	}
+	for turn := 0; ; turn++ {
+		// the ant in queue position turn leaves the start room now, so every path needs to know its ant for that position
+		for needed := true; needed && !f.done(); {
+			needed = false
+			for p := range window {
+				if first[p]+len(window[p]) <= turn {
+					needed = true
+				}
+			}
+			if needed {
+				p, ant := f.feed()
+				window[p] = append(window[p], ant)
+			}
+		}
+
+		moves := []move{}
+		for p, ants := range window {
+			for i, ant := range ants {
+				room := turn - (first[p] + i)
+				if room < 0 {
+					break
+				}
+				moves = append(moves, move{ant, pathLists[p][room]})
+			}
+			// the ants that reached the end room this turn are done
+			for len(window[p]) > 0 && turn-first[p] >= len(pathLists[p])-1 {
+				window[p] = window[p][1:]
+				first[p]++
+			}
+		}
+		if len(moves) == 0 {
+			break
+		}
+
+		sort.Slice(moves, func(i, j int) bool {
+			return moves[i].ant < moves[j].ant
+		})
+		for i, m := range moves {
+			if i > 0 {
+				out.WriteByte(' ')
+			}
+			out.WriteString("L" + strconv.Itoa(m.ant) + "-" + m.room)
+		}
+		// the writer keeps its first error, so the end of the line reports any write of the line that failed
+		if err := out.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestStreamMoves(t *testing.T) {
+	for _, paths := range [][]string{
+		{"e"},
+		{"a-e"},
+		{"a-e", "b-c-e"},
+		{"a-e", "b-c-d-f-e", "g-h-e"},
+		{"e", "a-b-c-d-e"},
+	} {
+		for _, ants := range []int{1, 2, 5, 17, 100} {
+			name := fmt.Sprintf("%d ants on %v", ants, paths)
+			want := AntSender(ants, paths)
+			var out bytes.Buffer
+			if err := StreamMoves(&out, ants, paths); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimSuffix(out.String(), "\n"); got != strings.Join(want, "\n") {
+				t.Errorf("%v: streamed\n%v\nwant\n%v", name, got, strings.Join(want, "\n"))
+			}
+			if turns := TurnCount(ants, paths); turns != len(want) {
+				t.Errorf("%v: counted %d turns, want %d", name, turns, len(want))
+			}
+			total := 0
+			for _, count := range AntsPerPath(ants, paths) {
+				total += count
+			}
+			if total != ants {
+				t.Errorf("%v: %d ants spread over the paths", name, total)
+			}
+		}
+	}
+}
+
+func TestWriteMovesManyAnts(t *testing.T) {
+	solution := &Solution{Ants: 200000, Paths: []string{"a-e", "b-c-e"}}
+	solution.Turns = TurnCount(solution.Ants, solution.Paths)
+	var out bytes.Buffer
+	if err := solution.WriteMoves(&out); err != nil {
+		t.Fatal(err)
+	}
+	if solution.Steps != nil {
+		t.Errorf("the moves were built in memory instead of streamed")
+	}
+	if lines := strings.Count(out.String(), "\n"); lines != solution.Turns {
+		t.Errorf("%d lines for %d turns", lines, solution.Turns)
+	}
+}
+
+// failingWriter fails every write, like a connection whose reader has gone
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return 0, errors.New("connection closed")
+}
+
+func TestStreamMovesStopsOnWriteError(t *testing.T) {
+	w := &failingWriter{}
+	// a million ants would take a long time to schedule to the end
+	if err := StreamMoves(w, 1000000, []string{"a-e", "b-c-e"}); err == nil || err.Error() != "connection closed" {
+		t.Errorf("got %v instead of the write error", err)
+	}
+	if w.writes != 1 {
+		t.Errorf("%d writes after the first one failed", w.writes-1)
+	}
+	w = &failingWriter{}
+	solution := &Solution{Ants: 3, Steps: []string{"L1-e", "L2-e", "L3-e"}}
+	if err := solution.WriteMoves(w); err == nil {
+		t.Errorf("the moves of a built schedule were written without an error")
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Summary describes a schedule: how long it takes, which paths it uses and how it was found
+type Summary struct {
+	Turns      int
+	PathLength []int // moves needed to walk every path of the solution
+	PathAnts   []int // ants sent along every path of the solution
+	Replanned  int   // ants that didn't stay on one of the paths because a room or tunnel closed
+	Strategy   string
+	SolveTime  time.Duration
+}
+
+// Summarize counts how the ants of a solution were spread over its paths
+func Summarize(solution *Solution, start string, solveTime time.Duration) Summary {
+	s := Summary{
+		Turns:      solution.Turns,
+		PathLength: make([]int, len(solution.Paths)),
+		PathAnts:   make([]int, len(solution.Paths)),
+		Strategy:   solution.Strategy,
+		SolveTime:  solveTime,
+	}
+	for i, path := range solution.Paths {
+		s.PathLength[i] = len(strings.Split(path, "-"))
+	}
+	if !solution.Replanned {
+		s.PathAnts = AntsPerPath(solution.Ants, solution.Paths)
+		return s
+	}
+	for _, itinerary := range Itineraries(solution, start) {
+		if itinerary.Path == 0 {
+			s.Replanned++
+			continue
+		}
+		s.PathAnts[itinerary.Path-1]++
+	}
+	return s
+}
+
+// Print writes the summary one fact per line, every line starting with prefix
+func (s Summary) Print(w io.Writer, prefix string) {
+	used := 0
+	for _, ants := range s.PathAnts {
+		if ants > 0 {
+			used++
+		}
+	}
+	fmt.Fprintf(w, "%vturns: %d\n", prefix, s.Turns)
+	fmt.Fprintf(w, "%vpaths used: %d\n", prefix, used)
+	for i, length := range s.PathLength {
+		fmt.Fprintf(w, "%vpath %d: length %d, %d ants\n", prefix, i+1, length, s.PathAnts[i])
+	}
+	if s.Replanned > 0 {
+		fmt.Fprintf(w, "%vre-planned ants: %d\n", prefix, s.Replanned)
+	}
+	fmt.Fprintf(w, "%vstrategy: %v\n", prefix, s.Strategy)
+	fmt.Fprintf(w, "%vsolve time: %v\n", prefix, s.SolveTime)
+}
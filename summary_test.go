@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSummary(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		colony  string
+		summary string
+	}{
+		{"two paths", "3\n##start\ns 0 0\na 1 0\nb 1 1\nc 2 1\n##end\ne 2 0\ns-a\na-e\ns-b\nb-c\nc-e", `# turns: 3
+# paths used: 2
+# path 1: length 2, 2 ants
+# path 2: length 3, 1 ants
+# strategy: DFS
+# solve time: 2ms
+`},
+		{"re-planned", "4\n##start\ns 0 0\na 1 0\nb 1 1\nc 2 1\n##end\ne 2 0\ns-a\na-e\ns-b\nb-c\nc-e\na-c\n##block 2 a-e", `# turns: 6
+# paths used: 1
+# path 1: length 2, 0 ants
+# path 2: length 3, 1 ants
+# re-planned ants: 3
+# strategy: DFS
+# solve time: 2ms
+`},
+	} {
+		g, _, err := ParseGraph(strings.NewReader(test.colony))
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		solution, err := Solve(g)
+		if err != nil {
+			t.Errorf("%v: %v", test.name, err)
+			continue
+		}
+		var out bytes.Buffer
+		Summarize(solution, g.StartRoomName, 2*time.Millisecond).Print(&out, "# ")
+		if out.String() != test.summary {
+			t.Errorf("%v: got\n%v\nwant\n%v", test.name, out.String(), test.summary)
+		}
+	}
+}
+
+func TestSummaryFlag(t *testing.T) {
+	stdout, stderr, code := run(t, "--summary", "comments", "example00.txt")
+	if code != ExitOK || stderr != "" || !strings.Contains(stdout, "L4-1\n# turns: 6\n# paths used: 1\n") {
+		t.Errorf("--summary comments printed\n%v\nand\n%v\nwith exit code %d", stdout, stderr, code)
+	}
+	// the comments don't get in the way of verify
+	_, steps, err := SplitOutput(stdout)
+	if err != nil || len(steps) != 6 {
+		t.Errorf("the moves are %q, %v", steps, err)
+	}
+
+	stdout, stderr, code = run(t, "--summary", "stderr", "--quiet", "example00.txt")
+	if code != ExitOK || strings.Contains(stdout, "#") || !strings.HasPrefix(stderr, "turns: 6\n") {
+		t.Errorf("--summary stderr printed\n%v\nand\n%v\nwith exit code %d", stdout, stderr, code)
+	}
+	if _, _, code := run(t, "--summary", "stdout", "example00.txt"); code != ExitUsage {
+		t.Errorf("--summary stdout: exit code %d, want %d", code, ExitUsage)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WhitespacePolicy decides how the fields of a room line are separated
+type WhitespacePolicy int
+
+const (
+	// WhitespaceSingle separates fields by exactly one ASCII space, as task.md writes them
+	WhitespaceSingle WhitespacePolicy = iota
+	// WhitespaceRuns separates fields by any run of Unicode whitespace, tabs included, and ignores it around a line
+	WhitespaceRuns
+)
+
+// ParseWhitespacePolicy reads a policy by its flag name, "single" or "runs"
+func ParseWhitespacePolicy(s string) (WhitespacePolicy, error) {
+	switch s {
+	case "single":
+		return WhitespaceSingle, nil
+	case "runs":
+		return WhitespaceRuns, nil
+	}
+	return WhitespaceSingle, fmt.Errorf("whitespace policy must be \"single\" or \"runs\", not %q", s)
+}
+
+// Trim removes the whitespace around a line when the policy ignores it
+func (p WhitespacePolicy) Trim(line string) string {
+	if p == WhitespaceRuns {
+		return strings.TrimSpace(line)
+	}
+	return line
+}
+
+// Fields splits a line into its fields. Names are never changed, so whatever Unicode a room name
+// has comes back unchanged in the Lx-room moves
+func (p WhitespacePolicy) Fields(line string) []string {
+	if p == WhitespaceRuns {
+		return strings.Fields(line)
+	}
+	return strings.Split(line, " ")
+}
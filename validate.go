@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ValidateRoomName checks a room name against the rules of task.md: a name is not empty, has no spaces,
+// never starts with L or # and has no - so that links stay unambiguous. Any other Unicode is allowed,
+// as long as the name is valid UTF-8 and has no control characters
+func ValidateRoomName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("room name is empty")
+	case strings.HasPrefix(name, "L"):
+		return fmt.Errorf("room name %q starts with L", name)
+	case strings.HasPrefix(name, "#"):
+		return fmt.Errorf("room name %q starts with #", name)
+	case !utf8.ValidString(name):
+		return fmt.Errorf("room name %q is not valid UTF-8", name)
+	case strings.IndexFunc(name, unicode.IsSpace) >= 0:
+		return fmt.Errorf("room name %q contains a space", name)
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return fmt.Errorf("room name %q contains a control character", name)
+	case strings.Contains(name, "-"):
+		return fmt.Errorf("room name %q contains -", name)
+	}
+	return nil
+}
+
+// ParseCoordinate reads a room coordinate, which has to be an int
+func ParseCoordinate(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, fmt.Errorf("coordinate %v doesn't fit in an int", s)
+		}
+		return 0, fmt.Errorf("coordinate %q is not an int", s)
+	}
+	return n, nil
+}
+
+// RunValidate checks that a colony can be solved without solving it, and returns the exit code
+func RunValidate(args []string) int {
+	flags := newFlagSet("validate", "[flags] <filename|->", "Checks that a colony follows the format and that a path leads from ##start to ##end.")
+	parseOptions := addParseOptionFlags(flags)
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitUsage
+	}
+	options, err := parseOptions.Options()
+	if err != nil {
+		fmt.Println(err)
+		return ExitUsage
+	}
+	input, err := openInput(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		return ExitUsage
+	}
+	defer input.Close()
+
+	options.DiscardLines = true
+	solved, err := ReadColony(input, options)
+	if err != nil {
+		fmt.Println(err)
+		return ExitCode(err)
+	}
+	fmt.Printf("%v: valid, %d ants\n", flags.Arg(0), solved.Graph.Ants)
+	return ExitOK
+}
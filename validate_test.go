@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateRoomName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"room", true},
+		{"0", true},
+		{"bar", true},
+		{"aL", true},
+		{"a#", true},
+		{"l", true},
+		{"", false},
+		{"L", false},
+		{"Lroom", false},
+		{"#room", false},
+		{"##start", false},
+		{"a-b", false},
+		{"-a", false},
+		{"a b", false},
+		{"a\tb", false},
+		{"météo", true},
+		{"日本", true},
+		{"🐜", true},
+		{"a\u00a0b", false},
+		{"a\x00b", false},
+		{"\xffroom", false},
+	}
+	for _, test := range tests {
+		if err := ValidateRoomName(test.name); (err == nil) != test.valid {
+			t.Errorf("ValidateRoomName(%q) = %v, want valid %v", test.name, err, test.valid)
+		}
+	}
+}
+
+func TestParseCoordinate(t *testing.T) {
+	tests := []struct {
+		coordinate string
+		want       int
+		valid      bool
+	}{
+		{"0", 0, true},
+		{"42", 42, true},
+		{"-7", -7, true},
+		{"9223372036854775807", 9223372036854775807, true},
+		{"9223372036854775808", 0, false},
+		{"-9223372036854775809", 0, false},
+		{"1.5", 0, false},
+		{"x", 0, false},
+		{"", 0, false},
+	}
+	for _, test := range tests {
+		got, err := ParseCoordinate(test.coordinate)
+		if (err == nil) != test.valid || got != test.want {
+			t.Errorf("ParseCoordinate(%q) = %v, %v, want %v valid %v", test.coordinate, got, err, test.want, test.valid)
+		}
+	}
+}
+
+func TestParserRules(t *testing.T) {
+	const rooms = "2\n##start\nstart 0 0\nbar 1 1\n##end\nend 2 2\n"
+	tests := []struct {
+		name   string
+		colony string
+		err    string // part of the error, empty when the colony is valid
+	}{
+		{"valid", rooms + "start-bar\nbar-end", ""},
+		{"link to a name that is part of another name", rooms + "start-bar\nar-end", "Room doesn't exist (ar)"},
+		{"link to an unknown room", rooms + "start-bar\nbar-nowhere", "Room doesn't exist (nowhere)"},
+		{"room starting with L", "2\n##start\nstart 0 0\nLroom 1 1\n##end\nend 2 2\nstart-Lroom\nLroom-end", "starts with L"},
+		{"room containing -", "2\n##start\nstart 0 0\na-b 1 1\n##end\nend 2 2\nstart-end", "contains -"},
+		{"coordinate out of range", "2\n##start\nstart 0 0\nbar 99999999999999999999 1\n##end\nend 2 2\nstart-bar\nbar-end", "doesn't fit in an int"},
+		{"coordinate not an int", "2\n##start\nstart 0 0\nbar 1 y\n##end\nend 2 2\nstart-bar\nbar-end", "is not an int"},
+		{"duplicate room name", rooms + "bar 5 5\nstart-bar\nbar-end", "Duplicate room names"},
+		{"duplicate coordinates", rooms + "baz 1 1\nstart-bar\nbar-end\nbaz-end", "Duplicate coordinates"},
+		{"self-link", rooms + "start-bar\nbar-bar\nbar-end", "same room to same room"},
+		{"duplicate link", rooms + "start-bar\nbar-end\nend-bar", "Duplicate Link"},
+		{"link with three rooms", rooms + "start-bar-end", "2 or more dashes"},
+		{"room after the links", rooms + "start-bar\nbaz 5 5\nbar-end", "continuous in the end"},
+		{"unlinked room", rooms + "baz 5 5\nstart-bar\nbar-end", "not connected"},
+		{"no start", "2\nstart 0 0\n##end\nend 2 2\nstart-end", "No ##start or ##end"},
+		{"two starts", "2\n##start\nstart 0 0\n##start\nbar 1 1\n##end\nend 2 2\nstart-bar\nbar-end", "More than one ##start"},
+		{"start without a room", "2\n##end\nend 2 2\n##start\nstart-end", "##start must be followed by a room"},
+		{"no ants", "0\n##start\nstart 0 0\n##end\nend 2 2\nstart-end", "Number of ants"},
+		{"ants not a number", "two\n##start\nstart 0 0\n##end\nend 2 2\nstart-end", "First line is not a number"},
+	}
+	for _, test := range tests {
+		_, _, err := ParseGraph(strings.NewReader(test.colony))
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%v: unexpected error %v", test.name, err)
+		case test.err != "" && err == nil:
+			t.Errorf("%v: the colony was accepted", test.name)
+		case test.err != "" && !strings.Contains(err.Error(), test.err):
+			t.Errorf("%v: got %v, want an error about %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestWhitespacePolicy(t *testing.T) {
+	colony := "2\n##start\nstart\t0  0\n  café 1 1 \n##end\nend 2 2\nstart-café\n café-end"
+	if _, _, err := ParseGraph(strings.NewReader(colony)); err == nil {
+		t.Errorf("single spaces: the colony was accepted")
+	}
+	g, _, err := ParseGraphWith(strings.NewReader(colony), ParseOptions{Whitespace: WhitespaceRuns})
+	if err != nil {
+		t.Fatalf("runs of whitespace: %v", err)
+	}
+	solution, err := Solve(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"L1-café", "L1-end L2-café", "L2-end"}
+	if got := solution.Moves(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got moves %q, want %q", got, want)
+	}
+
+	// the flag gives solve the same policy, the colony is printed as it was read
+	file := filepath.Join(t.TempDir(), "tabs.txt")
+	if err := os.WriteFile(file, []byte(colony), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, code := run(t, file); code != ExitInvalid {
+		t.Errorf("solve: exit code %d, want %d", code, ExitInvalid)
+	}
+	stdout, _, code := run(t, "--whitespace", "runs", file)
+	if code != ExitOK || stdout != colony+"\n\n"+strings.Join(want, "\n")+"\n" {
+		t.Errorf("solve --whitespace runs printed\n%v\nwith exit code %d", stdout, code)
+	}
+	if _, _, code := run(t, "--whitespace", "tabs", file); code != ExitUsage {
+		t.Errorf("--whitespace tabs: exit code %d, want %d", code, ExitUsage)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	colony := "2\n##start\nstart 0 0\n##zone north\nbar 1 1\n##end\nend 2 2\n##zone south\nstart-bar\nbar-end"
+	if _, _, err := ParseGraph(strings.NewReader(colony)); err != nil {
+		t.Errorf("spec mode: %v", err)
+	}
+	_, _, err := ParseGraphWith(strings.NewReader(colony), ParseOptions{CommandMode: CommandsStrict})
+	if err == nil || !strings.Contains(err.Error(), "Unknown command ##zone (line 4)") {
+		t.Errorf("strict mode: got %v, want an unknown command error", err)
+	}
+
+	commands := DefaultCommands()
+	commands["##zone"] = func(call *CommandCall) error {
+		if len(call.Args) != 1 {
+			return errors.New("##zone needs a name")
+		}
+		call.Attach("zone", call.Args[0])
+		return nil
+	}
+	g, _, err := ParseGraphWith(strings.NewReader(colony), ParseOptions{Commands: commands, CommandMode: CommandsStrict})
+	if err != nil {
+		t.Fatalf("custom command: %v", err)
+	}
+	if zone := g.getRoom("bar").Meta["zone"]; zone != "north" {
+		t.Errorf("room bar is in zone %q, want north", zone)
+	}
+	if zone := g.LinkMeta[tunnelKey("start", "bar")]["zone"]; zone != "south" {
+		t.Errorf("link start-bar is in zone %q, want south", zone)
+	}
+	if g.getRoom("end").Meta != nil || g.LinkMeta[tunnelKey("bar", "end")] != nil {
+		t.Errorf("metadata was attached to more than the next room or link")
+	}
+
+	_, _, err = ParseGraphWith(strings.NewReader(strings.Replace(colony, "##zone north", "##zone", 1)), ParseOptions{Commands: commands})
+	if err == nil || !strings.Contains(err.Error(), "##zone needs a name (line 4)") {
+		t.Errorf("failing command: got %v", err)
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	colony := "3\n#@zone=north\n##start\ns 0 0\n#@colour=red\n#@zone=hall\nhub 1 0\n# plain\n#@ignored\n##end\ne 2 0\n#@kind=a=b\ns-hub\nhub-e"
+	g, _, err := ParseGraph(strings.NewReader(colony))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta := g.RoomMeta("s"); len(meta) != 1 || meta["zone"] != "north" {
+		t.Errorf("room s has metadata %v, want zone=north", meta)
+	}
+	if meta := g.RoomMeta("hub"); len(meta) != 2 || meta["colour"] != "red" || meta["zone"] != "hall" {
+		t.Errorf("room hub has metadata %v, want colour=red and zone=hall", meta)
+	}
+	if meta := g.RoomMeta("e"); meta != nil {
+		t.Errorf("room e has metadata %v, want none", meta)
+	}
+	if meta := g.LinkMetaOf("hub", "s"); meta["kind"] != "a=b" {
+		t.Errorf("link s-hub has metadata %v, want kind=a=b", meta)
+	}
+
+	solution, err := Solve(g)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := NewJSONReport(g)
+	report.AddSolution(solution, g.StartRoomName)
+	var out strings.Builder
+	if err := report.Write(&out); err != nil {
+		t.Fatal(err)
+	}
+	var decoded JSONReport
+	if err := json.Unmarshal([]byte(out.String()), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Rooms[1].Meta["colour"] != "red" || decoded.Links[0].Meta["kind"] != "a=b" || decoded.Links[1].Meta != nil {
+		t.Errorf("the metadata didn't make it into the JSON output:\n%v", out.String())
+	}
+	if decoded.Turns != 4 || strings.Join(decoded.Paths[0], "-") != "s-hub-e" || len(decoded.Moves) != 4 {
+		t.Errorf("unexpected solution in the JSON output:\n%v", out.String())
+	}
+}
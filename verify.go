@@ -0,0 +1,164 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Verify replays the moves of every turn on the colony and checks that they follow the rules: ants only walk through tunnels,
+// every room but start and end holds at most one ant at the end of a turn, every tunnel is used at most once per turn,
+// every ant moves at most once per turn, no ant enters a room or tunnel once a ##block has closed it,
+// and all the ants are in the end room after the last turn, having passed every checkpoint
+func Verify(g *Graph, steps []string) error {
+	neighbours := undirected(g)
+	position := make([]string, g.Ants+1)
+	for ant := 1; ant <= g.Ants; ant++ {
+		position[ant] = g.StartRoomName
+	}
+	occupant := map[string]int{}
+	passed := map[int]map[string]bool{}
+	// the first turn every blocked room and tunnel is closed from
+	closedFrom := map[string]int{}
+	for _, block := range g.Blocks {
+		key := block.Room
+		if key == "" {
+			key = tunnelKey(block.From, block.To)
+		}
+		if first, ok := closedFrom[key]; !ok || block.Turn < first {
+			closedFrom[key] = block.Turn
+		}
+	}
+	closed := func(key string, turn int) bool {
+		first, ok := closedFrom[key]
+		return ok && turn >= first
+	}
+
+	for i, step := range steps {
+		turn := i + 1
+		if strings.TrimSpace(step) == "" {
+			return fmt.Errorf("turn %d: no ant moves", turn)
+		}
+		moved := map[int]bool{}
+		tunnels := map[string]bool{}
+		for _, move := range strings.Split(step, " ") {
+			if !strings.HasPrefix(move, "L") || !strings.Contains(move, "-") {
+				return fmt.Errorf("turn %d: %q is not an Lx-y move", turn, move)
+			}
+			parts := strings.SplitN(move[1:], "-", 2)
+			ant, err := strconv.Atoi(parts[0])
+			if err != nil || ant < 1 || ant > g.Ants {
+				return fmt.Errorf("turn %d: there is no ant %v", turn, parts[0])
+			}
+			room := parts[1]
+			from := position[ant]
+			switch {
+			case moved[ant]:
+				return fmt.Errorf("turn %d: ant %d moves twice", turn, ant)
+			case from == g.EndRoomName:
+				return fmt.Errorf("turn %d: ant %d moves after reaching the end room", turn, ant)
+			case !contains(neighbours[from], room):
+				return fmt.Errorf("turn %d: there is no tunnel from %v to %v for ant %d", turn, from, room, ant)
+			case tunnels[tunnelKey(from, room)]:
+				return fmt.Errorf("turn %d: the tunnel %v is used twice", turn, tunnelKey(from, room))
+			case closed(room, turn):
+				return fmt.Errorf("turn %d: ant %d moves into the closed room %v", turn, ant, room)
+			case closed(tunnelKey(from, room), turn):
+				return fmt.Errorf("turn %d: ant %d moves through the closed tunnel %v", turn, ant, tunnelKey(from, room))
+			}
+			moved[ant] = true
+			if contains(g.Checkpoints, room) {
+				if passed[ant] == nil {
+					passed[ant] = map[string]bool{}
+				}
+				passed[ant][room] = true
+			}
+			tunnels[tunnelKey(from, room)] = true
+			if occupant[from] == ant {
+				delete(occupant, from)
+			}
+			position[ant] = room
+		}
+		// a room can be entered in the same turn its ant leaves it, so only the rooms at the end of the turn count
+		for ant := range moved {
+			room := position[ant]
+			if room == g.EndRoomName {
+				continue
+			}
+			if other, ok := occupant[room]; ok && other != ant {
+				return fmt.Errorf("turn %d: ants %d and %d are both in room %v", turn, other, ant, room)
+			}
+			occupant[room] = ant
+		}
+	}
+
+	for ant := 1; ant <= g.Ants; ant++ {
+		if position[ant] != g.EndRoomName {
+			return fmt.Errorf("ant %d ends in room %v instead of the end room", ant, position[ant])
+		}
+		for _, checkpoint := range g.Checkpoints {
+			if !passed[ant][checkpoint] {
+				return fmt.Errorf("ant %d never passes the checkpoint %v", ant, checkpoint)
+			}
+		}
+	}
+	return nil
+}
+
+// SplitOutput splits the output of solve into the colony and the moves of every turn. Comments after the moves,
+// like the summary of --summary comments, are left out
+func SplitOutput(text string) (string, []string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	parts := strings.SplitN(text, "\n\n", 2)
+	if len(parts) != 2 {
+		return "", nil, errors.New("the colony has to be followed by an empty line and the moves")
+	}
+	steps := []string{}
+	for _, line := range strings.Split(strings.TrimRight(parts[1], "\n"), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			steps = append(steps, line)
+		}
+	}
+	return parts[0], steps, nil
+}
+
+// RunVerify checks the output of solve, read from a file or stdin, and returns the exit code: 1 when the moves break the rules
+func RunVerify(args []string) int {
+	flags := newFlagSet("verify", "<filename|->", "Replays the output of solve, the colony, an empty line and the moves, and checks that the moves follow the rules.")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitUsage
+	}
+	input, err := openInput(flags.Arg(0))
+	if err != nil {
+		fmt.Println(err)
+		return ExitUsage
+	}
+	defer input.Close()
+	data, err := io.ReadAll(input)
+	if err != nil {
+		fmt.Println(err)
+		return ExitUsage
+	}
+	colony, steps, err := SplitOutput(string(data))
+	if err != nil {
+		fmt.Println(err)
+		return ExitInvalid
+	}
+	g, _, err := ParseGraph(strings.NewReader(colony))
+	if err != nil {
+		fmt.Println(err)
+		return ExitCode(err)
+	}
+	if err := Verify(g, steps); err != nil {
+		fmt.Println(err)
+		return ExitInvalid
+	}
+	fmt.Printf("%v: valid, %d ants in %d turns\n", flags.Arg(0), g.Ants, len(steps))
+	return ExitOK
+}